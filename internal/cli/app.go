@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -23,9 +26,19 @@ const (
 	usageFormat = `usage: stratus [options] %[1]s
 
 [options]
+--allow-destructive execute changes blocked by stack protections
+--auto-recover continue a failed rollback or delete a failed create before staging
+--cancel-on-interrupt cancel stack updates on SIGINT or SIGTERM without prompting (other operations carry on)
+--concurrency maximum stacks to run at once (default 1)
+--env apply the named environment overlay from the config (default none)
 --file path%[2]cto%[2]cstratus.json|yaml (default .%[2]cstratus.yaml)
 --name select specific stack (default select all stacks)
 --output %[3]s (default plain)
+--outputs-file write stack outputs to a file after deploy or outputs (default $GITHUB_OUTPUT for github)
+--outputs-format %[4]s (default json)
+--poll-interval longest delay between status checks, e.g. 15s (default 30s)
+--skip-resources comma-separated logical IDs to skip when continuing a failed rollback
+--timeout how long to wait on each stack operation, e.g. 45m (default 2h)
 `
 )
 
@@ -37,17 +50,31 @@ func init() {
 			commandNames,
 			os.PathSeparator,
 			loggerNames,
+			strings.Join(stratus.OutputsFormats, "|"),
 		)
 	}
 }
 
 type App struct {
-	cfg       *config.Config
-	command   Command
-	logger    log.Logger
-	stackName string
-
-	newClient func(region *string) *stratus.Client
+	allowDestructive  bool
+	autoRecover       bool
+	cancelOnInterrupt bool
+	cfg               *config.Config
+	command           Command
+	concurrency       int
+	logger            log.Logger
+	offline           bool
+	outputsFile       string
+	outputsFormat     string
+	pollInterval      time.Duration
+	resourcesToSkip   []string
+	stackName         string
+	summaryCommand    SummaryCommand
+	teardown          bool
+	timeout           time.Duration
+
+	newClient clientFactory
+	outputs   *outputCache
 }
 
 func New() (_ *App, err error) {
@@ -57,12 +84,42 @@ func New() (_ *App, err error) {
 		}
 	}()
 
+	allowDestructive := flag.Bool("allow-destructive", false, "execute changes blocked by stack protections")
+	autoRecover := flag.Bool("auto-recover", false, "recover stacks that block change sets before staging")
+	cancelOnInterrupt := flag.Bool("cancel-on-interrupt", false, "cancel stack updates on interrupt without prompting")
 	cfgPath := flag.String("file", "stratus.yaml", "config file")
+	concurrency := flag.Int("concurrency", 1, "maximum stacks to run at once")
+	environment := flag.String("env", "", "environment overlay")
 	rawStackName := flag.String("name", "", "stack name")
+	rawResourcesToSkip := flag.String("skip-resources", "", "logical IDs to skip when continuing a failed rollback")
 	loggerName := flag.String("output", "plain", "output format")
+	outputsFile := flag.String("outputs-file", "", "file to write stack outputs to")
+	outputsFormat := flag.String("outputs-format", stratus.OutputsFormatJSON, "stack outputs file format")
+	pollInterval := flag.Duration("poll-interval", 0, "longest delay between status checks")
+	timeout := flag.Duration("timeout", 0, "how long to wait on each stack operation")
 
 	flag.Parse()
 
+	if *concurrency < 1 {
+		return nil, fmt.Errorf("concurrency '%d' must be at least 1", *concurrency)
+	}
+
+	if *pollInterval < 0 {
+		return nil, fmt.Errorf("poll interval '%s' must not be negative", *pollInterval)
+	}
+
+	if *timeout < 0 {
+		return nil, fmt.Errorf("timeout '%s' must not be negative", *timeout)
+	}
+
+	if !containsString(stratus.OutputsFormats, *outputsFormat) {
+		return nil, fmt.Errorf("outputs format '%s' not recognised", *outputsFormat)
+	}
+
+	if *outputsFile == "" && *outputsFormat == stratus.OutputsFormatGitHub {
+		*outputsFile = os.Getenv("GITHUB_OUTPUT")
+	}
+
 	logger, ok := nameToLogger[*loggerName]
 	if !ok {
 		return nil, fmt.Errorf("output '%s' not recognised", *loggerName)
@@ -71,7 +128,8 @@ func New() (_ *App, err error) {
 	commandName := flag.Arg(0)
 
 	command, ok := nameToCommand[commandName]
-	if !ok {
+	summaryCommand, isSummary := summaryCommands[commandName]
+	if !ok && !isSummary {
 		return nil, fmt.Errorf("command '%s' not recognised", commandName)
 	}
 
@@ -86,10 +144,13 @@ func New() (_ *App, err error) {
 
 	newClient := newClientFactory(provider)
 
-	// TODO: can we support per-stack regional parameters?
-	config.Init(provider)
+	if offlineCommands[commandName] {
+		config.InitOffline()
+	} else {
+		config.Init(provider)
+	}
 
-	cfg, err := config.FromPath(*cfgPath)
+	cfg, err := config.FromPathWithEnvironment(*cfgPath, *environment)
 	if err != nil {
 		return nil, err
 	}
@@ -100,12 +161,25 @@ func New() (_ *App, err error) {
 	}
 
 	app := &App{
-		cfg:       cfg,
-		command:   command,
-		logger:    logger,
-		stackName: stackName,
+		allowDestructive:  *allowDestructive,
+		autoRecover:       *autoRecover,
+		cancelOnInterrupt: *cancelOnInterrupt,
+		cfg:               cfg,
+		command:           command,
+		concurrency:       *concurrency,
+		logger:            logger,
+		offline:           offlineCommands[commandName],
+		outputsFile:       *outputsFile,
+		outputsFormat:     *outputsFormat,
+		pollInterval:      *pollInterval,
+		resourcesToSkip:   toResourcesToSkip(*rawResourcesToSkip),
+		stackName:         stackName,
+		summaryCommand:    summaryCommand,
+		teardown:          teardownCommands[commandName],
+		timeout:           *timeout,
 
 		newClient: newClient,
+		outputs:   newOutputCache(),
 	}
 
 	return app, nil
@@ -113,6 +187,31 @@ func New() (_ *App, err error) {
 
 func (app *App) Do(ctx context.Context) error {
 	ctx = context.WithLogger(ctx, app.logger)
+	ctx = context.WithAllowDestructive(ctx, app.allowDestructive)
+	ctx = context.WithAutoRecover(ctx, app.autoRecover)
+	ctx = context.WithConfirmCancelUpdate(ctx, newConfirmCancelUpdate(app.cancelOnInterrupt))
+	ctx = context.WithPollInterval(ctx, app.pollInterval)
+	ctx = context.WithResourcesToSkip(ctx, app.resourcesToSkip)
+	ctx = context.WithWaitTimeout(ctx, app.timeout)
+
+	if app.outputsFile == "" {
+		return app.do(ctx)
+	}
+
+	export := newOutputsExport()
+
+	err := app.do(context.WithOutputsRecorder(ctx, export.record))
+	if err != nil {
+		return err
+	}
+
+	return export.write(app.outputsFile, app.outputsFormat)
+}
+
+func (app *App) do(ctx context.Context) error {
+	if app.summaryCommand != nil {
+		return app.doSummary(ctx)
+	}
 
 	if app.stackName == "" {
 		return app.doAll(ctx)
@@ -123,24 +222,27 @@ func (app *App) Do(ctx context.Context) error {
 		return fmt.Errorf("stack '%s' not found in config", app.stackName)
 	}
 
-	app.logger.Title("Load config")
-	app.logger.Data(stack)
+	logger := log.WithStack(app.logger, stack.Name)
 
-	client := app.newClient(stack.Region)
-
-	return app.command(context.WithLogger(ctx, app.logger), client, stack)
+	return app.doOne(ctx, logger, stack, "Load config")
 }
 
 func (app *App) doAll(ctx context.Context) error {
-	for index := 0; index < len(app.cfg.Stacks); index++ {
-		stack := app.cfg.Stacks[index]
+	stacks := app.cfg.Stacks
+	if app.teardown {
+		stacks = stacks.Reverse()
+	}
 
-		app.logger.Title("Load config %d", index)
-		app.logger.Data(stack)
+	if app.concurrency > 1 {
+		return app.doConcurrent(ctx, stacks)
+	}
+
+	for index := 0; index < len(stacks); index++ {
+		title := fmt.Sprintf("Load config %d", index)
 
-		client := app.newClient(stack.Region)
+		logger := log.WithStack(app.logger, stacks[index].Name)
 
-		err := app.command(context.WithLogger(ctx, app.logger), client, stack)
+		err := app.doOne(ctx, logger, stacks[index], title)
 		if err != nil {
 			return err
 		}
@@ -149,29 +251,103 @@ func (app *App) doAll(ctx context.Context) error {
 	return nil
 }
 
-type clientFactory func(region *string) *stratus.Client
+func (app *App) doSummary(ctx context.Context) error {
+	stacks := app.cfg.Stacks
+
+	if app.stackName != "" {
+		stack, ok := stacks.Find(app.stackName)
+		if !ok {
+			return fmt.Errorf("stack '%s' not found in config", app.stackName)
+		}
+
+		stacks = config.Stacks{stack}
+	}
+
+	for _, stack := range stacks {
+		// an upstream stack or output may not exist yet, which leaves the
+		// stack's checksum unknown in the summary
+		err := app.resolveOutputs(ctx, stack)
+
+		var missing *missingOutputError
+		if errors.As(err, &missing) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return app.summaryCommand(ctx, app.newClient, stacks)
+}
+
+func (app *App) doOne(
+	ctx context.Context,
+	logger log.Logger,
+	stack *config.Stack,
+	title string,
+) error {
+	err := app.resolveOutputs(ctx, stack)
+	if err != nil {
+		return err
+	}
+
+	logger.Title(title)
+	logger.Data(stack)
+
+	client := app.newClient(stack)
+
+	return app.command(context.WithLogger(ctx, logger), client, stack)
+}
+
+func toResourcesToSkip(raw string) []string {
+	ids := make([]string, 0)
 
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+func containsString(slice []string, str string) bool {
+	for _, item := range slice {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+type clientFactory func(stack *config.Stack) *stratus.Client
+
+// newClientFactory shares a client per scope. The cache is locked as stacks
+// run concurrently under --concurrency.
 func newClientFactory(provider awsclient.ConfigProvider) clientFactory {
-	cache := make(map[*string]*stratus.Client)
+	cache := make(map[config.Scope]*stratus.Client)
+	cacheLock := new(sync.Mutex)
+
+	return func(stack *config.Stack) *stratus.Client {
+		scope := stack.Scope()
 
-	return func(region *string) *stratus.Client {
-		cachedClient, ok := cache[region]
+		cacheLock.Lock()
+		defer cacheLock.Unlock()
+
+		cachedClient, ok := cache[scope]
 		if ok {
 			return cachedClient
 		}
 
-		regionConfig := aws.NewConfig()
-
-		if region != nil {
-			regionConfig = regionConfig.WithRegion(*region)
-		}
+		scopeConfig := scope.AWSConfig(provider)
 
-		cfnClient := cloudformation.New(provider, regionConfig)
-		s3Client := s3.New(provider, regionConfig)
+		cfnClient := cloudformation.New(provider, scopeConfig)
+		s3Client := s3.New(provider, scopeConfig)
 
 		newClient := stratus.NewClient(cfnClient, s3Client)
 
-		cache[region] = newClient
+		cache[scope] = newClient
 
 		return newClient
 	}
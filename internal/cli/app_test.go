@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/command"
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func Test_doSummary_UnresolvedOutputs(t *testing.T) {
+	testCases := []struct {
+		description string
+		value       string
+
+		expectedError string
+	}{
+		{
+			description: "missing output",
+			value:       "${stack:upstream:output:Missing}",
+		},
+		{
+			description: "missing stack",
+			value:       "${stack:pending:output:BucketName}",
+		},
+		{
+			description:   "stack not in config",
+			value:         "${stack:other:output:BucketName}",
+			expectedError: "stack 'downstream' parameter 'BucketName': stack 'other' not found in config",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfn := stratus.NewCloudFormationFake(nil)
+			client := stratus.NewClient(cfn, nil)
+
+			logger := new(recordingLogger)
+			ctx := context.WithLogger(context.Background(), logger)
+
+			upstream := newConcurrentStack(t, "upstream", fakeUpstreamTemplate, nil)
+			pending := newConcurrentStack(t, "pending", fakeUpstreamTemplate, nil)
+			deployed := newConcurrentStack(
+				t,
+				"downstream",
+				fakeDownstreamTemplate,
+				config.StackParameters{{Key: "BucketName", Value: "fake-bucket-name"}},
+			)
+
+			for _, stack := range (config.Stacks{upstream, deployed}) {
+				_, _, err := command.Stage(ctx, client, stack)
+				require.NoError(err)
+
+				err = command.Deploy(ctx, client, stack)
+				require.NoError(err)
+			}
+
+			downstream := newConcurrentStack(
+				t,
+				"downstream",
+				fakeDownstreamTemplate,
+				config.StackParameters{{Key: "BucketName", Value: testCase.value}},
+			)
+
+			app := &App{
+				cfg:            &config.Config{Stacks: config.Stacks{upstream, pending, downstream}},
+				logger:         logger,
+				summaryCommand: command.Status,
+
+				newClient: func(*config.Stack) *stratus.Client { return client },
+				outputs:   newOutputCache(),
+			}
+
+			err := app.doSummary(ctx)
+			if testCase.expectedError != "" {
+				assert.EqualError(err, testCase.expectedError)
+				return
+			}
+			require.NoError(err)
+
+			var summaries stratus.StackSummaries
+			for _, model := range logger.models {
+				if typed, ok := model.(stratus.StackSummaries); ok {
+					summaries = typed
+				}
+			}
+
+			require.Len(summaries, 3)
+			assert.True(summaries[0].ChecksumMatches)
+			assert.False(summaries[0].ChecksumUnknown)
+			assert.False(summaries[1].Exists)
+			assert.True(summaries[2].Exists)
+			assert.False(summaries[2].ChecksumMatches)
+			assert.True(summaries[2].ChecksumUnknown)
+		})
+	}
+}
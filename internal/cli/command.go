@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/72636c/stratus/internal/command"
@@ -11,9 +12,31 @@ import (
 
 var (
 	nameToCommand = map[string]Command{
-		"delete": command.Delete,
-		"deploy": command.Deploy,
-		"stage":  stageAdapter,
+		"delete":   command.Delete,
+		"deploy":   command.Deploy,
+		"drift":    command.Drift,
+		"outputs":  command.Outputs,
+		"recover":  command.Recover,
+		"stage":    stageAdapter,
+		"validate": command.Validate,
+	}
+
+	// summaryCommands report on every selected stack at once, rather than
+	// running per stack.
+	summaryCommands = map[string]SummaryCommand{
+		"status": command.Status,
+	}
+
+	// offlineCommands make no AWS calls, so stack output and AWS placeholders
+	// are left unresolved.
+	offlineCommands = map[string]bool{
+		"validate": true,
+	}
+
+	// teardownCommands run in reverse dependency order, and skip resolving stack
+	// output placeholders as the upstream stacks may already be gone.
+	teardownCommands = map[string]bool{
+		"delete": true,
 	}
 
 	commandNames = func() string {
@@ -23,12 +46,20 @@ var (
 			names = append(names, name)
 		}
 
+		for name := range summaryCommands {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
 		return strings.Join(names, "|")
 	}()
 )
 
 type Command func(context.Context, *stratus.Client, *config.Stack) error
 
+type SummaryCommand func(context.Context, func(*config.Stack) *stratus.Client, config.Stacks) error
+
 func stageAdapter(
 	ctx context.Context,
 	client *stratus.Client,
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/errgroup"
+	"github.com/72636c/stratus/internal/log"
+)
+
+const (
+	stackStatusFailed    = "failed"
+	stackStatusSkipped   = "skipped"
+	stackStatusSucceeded = "succeeded"
+
+	// bufferedFlushInterval is how often concurrently running stacks write the
+	// output they have buffered so far
+	bufferedFlushInterval = 10 * time.Second
+)
+
+type stackResults []*stackResult
+
+func (stackResults) LogType() string {
+	return "summary"
+}
+
+type stackResult struct {
+	Stack  string
+	Status string
+	Error  string `json:",omitempty"`
+}
+
+// doConcurrent runs up to app.concurrency stacks at a time. A stack starts
+// once the stacks it waits on have finished, and is skipped once any stack
+// has failed. Stacks already in flight are left to finish. Each stack's output
+// is buffered and flushed a section at a time, with titles naming the stack.
+func (app *App) doConcurrent(ctx context.Context, stacks config.Stacks) error {
+	waitsOn := app.newWaitsOn(stacks)
+
+	done := make(map[string]chan struct{}, len(stacks))
+	for _, stack := range stacks {
+		done[stack.Name] = make(chan struct{})
+	}
+
+	var (
+		failed    bool
+		failedMux sync.Mutex
+	)
+
+	hasFailed := func() bool {
+		failedMux.Lock()
+		defer failedMux.Unlock()
+		return failed
+	}
+
+	setFailed := func() {
+		failedMux.Lock()
+		failed = true
+		failedMux.Unlock()
+	}
+
+	results := make(stackResults, len(stacks))
+	semaphore := make(chan struct{}, app.concurrency)
+
+	group, _ := errgroup.WithContext(ctx)
+
+	for index, stack := range stacks {
+		results[index] = &stackResult{
+			Stack:  stack.Name,
+			Status: stackStatusSkipped,
+		}
+
+		group.Go(func() error {
+			defer close(done[stack.Name])
+
+			for _, name := range waitsOn[stack.Name] {
+				<-done[name]
+			}
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if hasFailed() {
+				return nil
+			}
+
+			logger := log.NewBufferedLogger(log.WithStackPrefix(app.logger, stack.Name))
+			defer logger.Flush()
+
+			stopFlushing := logger.FlushEvery(bufferedFlushInterval)
+			defer stopFlushing()
+
+			title := fmt.Sprintf("Load config %d", index)
+
+			err := app.doOne(context.WithLogger(ctx, logger), logger, stack, title)
+			if err != nil {
+				setFailed()
+
+				results[index].Status = stackStatusFailed
+				results[index].Error = err.Error()
+
+				return err
+			}
+
+			results[index].Status = stackStatusSucceeded
+
+			return nil
+		})
+	}
+
+	err := group.Wait()
+
+	app.logger.Title("Summary")
+	app.logger.Data(results)
+
+	return err
+}
+
+// newWaitsOn maps each stack to the stacks that must finish before it starts:
+// its dependencies, or its dependents when tearing down.
+func (app *App) newWaitsOn(stacks config.Stacks) map[string][]string {
+	waitsOn := make(map[string][]string, len(stacks))
+
+	for _, stack := range stacks {
+		for _, name := range stack.Dependencies() {
+			if app.teardown {
+				waitsOn[name] = append(waitsOn[name], stack.Name)
+			} else {
+				waitsOn[stack.Name] = append(waitsOn[stack.Name], name)
+			}
+		}
+	}
+
+	return waitsOn
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/command"
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+const (
+	fakeStackPolicy = `{"Statement":[{"Effect":"Allow","Action":"Update:*","Principal":"*","Resource":"*"}]}`
+
+	fakeUpstreamTemplate = `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+Outputs:
+  BucketName:
+    Value: fake-bucket-name
+`
+
+	fakeDownstreamTemplate = `
+Parameters:
+  BucketName:
+    Type: String
+Resources:
+  Topic:
+    Type: AWS::SNS::Topic
+`
+
+	fakeInvalidTemplate = `Resources: [`
+)
+
+type recordingLogger struct {
+	sync.Mutex
+	models []interface{}
+}
+
+func (logger *recordingLogger) Data(model interface{}) {
+	logger.Lock()
+	logger.models = append(logger.models, model)
+	logger.Unlock()
+}
+
+func (logger *recordingLogger) Title(string, ...interface{}) {}
+
+func (logger *recordingLogger) results() stackResults {
+	logger.Lock()
+	defer logger.Unlock()
+
+	for _, model := range logger.models {
+		if results, ok := model.(stackResults); ok {
+			return results
+		}
+	}
+
+	return nil
+}
+
+// recordingCommand stages and deploys stacks against a fake, recording the
+// order that stacks start and finish in and the most that ran at once.
+type recordingCommand struct {
+	sync.Mutex
+
+	delay time.Duration
+
+	events      []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (cmd *recordingCommand) do(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	cmd.record("start " + stack.Name)
+	defer cmd.record("finish " + stack.Name)
+
+	cmd.Lock()
+	cmd.inFlight++
+	if cmd.inFlight > cmd.maxInFlight {
+		cmd.maxInFlight = cmd.inFlight
+	}
+	cmd.Unlock()
+
+	defer func() {
+		cmd.Lock()
+		cmd.inFlight--
+		cmd.Unlock()
+	}()
+
+	time.Sleep(cmd.delay)
+
+	_, _, err := command.Stage(ctx, client, stack)
+	if err != nil {
+		return err
+	}
+
+	return command.Deploy(ctx, client, stack)
+}
+
+func (cmd *recordingCommand) record(event string) {
+	cmd.Lock()
+	cmd.events = append(cmd.events, event)
+	cmd.Unlock()
+}
+
+func (cmd *recordingCommand) indexOf(event string) int {
+	cmd.Lock()
+	defer cmd.Unlock()
+
+	for index, candidate := range cmd.events {
+		if candidate == event {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func newFakeApp(
+	cmd *recordingCommand,
+	concurrency int,
+	stacks config.Stacks,
+) (*App, *recordingLogger) {
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	logger := new(recordingLogger)
+
+	app := &App{
+		cfg:         &config.Config{Stacks: stacks},
+		command:     cmd.do,
+		concurrency: concurrency,
+		logger:      logger,
+
+		newClient: func(*config.Stack) *stratus.Client { return client },
+		outputs:   newOutputCache(),
+	}
+
+	return app, logger
+}
+
+func newConcurrentStack(
+	t *testing.T,
+	name string,
+	template string,
+	parameters config.StackParameters,
+) *config.Stack {
+	stack := &config.Stack{
+		Name: name,
+
+		Capabilities: make([]string, 0),
+		Parameters:   parameters,
+		Tags:         make(config.StackTags, 0),
+
+		Policy:   []byte(fakeStackPolicy),
+		Template: []byte(template),
+	}
+
+	checksum, err := config.CalculateChecksum(stack.Hashable())
+	require.NoError(t, err)
+
+	stack.Checksum = checksum
+
+	return stack
+}
+
+func Test_doConcurrent_WaitsOnDependencies(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	downstream := newConcurrentStack(
+		t,
+		"downstream",
+		fakeDownstreamTemplate,
+		config.StackParameters{
+			{Key: "BucketName", Value: "${stack:upstream:output:BucketName}"},
+		},
+	)
+	upstream := newConcurrentStack(t, "upstream", fakeUpstreamTemplate, nil)
+
+	cmd := &recordingCommand{delay: 10 * time.Millisecond}
+	app, logger := newFakeApp(cmd, 2, config.Stacks{downstream, upstream})
+
+	err := app.doConcurrent(context.Background(), app.cfg.Stacks)
+	require.NoError(err)
+
+	assert.True(cmd.indexOf("finish upstream") < cmd.indexOf("start downstream"))
+	assert.Equal("fake-bucket-name", downstream.Parameters[0].Value)
+
+	assert.Equal(
+		stackResults{
+			{Stack: "downstream", Status: stackStatusSucceeded},
+			{Stack: "upstream", Status: stackStatusSucceeded},
+		},
+		logger.results(),
+	)
+}
+
+func Test_doConcurrent_SkipsAfterFailure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	upstream := newConcurrentStack(t, "upstream", fakeInvalidTemplate, nil)
+	downstream := newConcurrentStack(t, "downstream", fakeDownstreamTemplate, nil)
+	downstream.DependsOn = []string{"upstream"}
+
+	cmd := new(recordingCommand)
+	app, logger := newFakeApp(cmd, 2, config.Stacks{upstream, downstream})
+
+	err := app.doConcurrent(context.Background(), app.cfg.Stacks)
+	require.Error(err)
+
+	assert.Equal(-1, cmd.indexOf("start downstream"))
+
+	results := logger.results()
+	require.Len(results, 2)
+	assert.Equal(stackStatusFailed, results[0].Status)
+	assert.Equal(err.Error(), results[0].Error)
+	assert.Equal(
+		&stackResult{Stack: "downstream", Status: stackStatusSkipped},
+		results[1],
+	)
+}
+
+func Test_doConcurrent_LimitsConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stacks := make(config.Stacks, 5)
+	for index := range stacks {
+		stacks[index] = newConcurrentStack(
+			t,
+			fmt.Sprintf("stack-%d", index),
+			fakeUpstreamTemplate,
+			nil,
+		)
+	}
+
+	cmd := &recordingCommand{delay: 20 * time.Millisecond}
+	app, logger := newFakeApp(cmd, 2, stacks)
+
+	err := app.doConcurrent(context.Background(), stacks)
+	require.NoError(err)
+
+	assert.True(cmd.maxInFlight <= 2, "ran %d stacks at once", cmd.maxInFlight)
+
+	for _, result := range logger.results() {
+		assert.Equal(stackStatusSucceeded, result.Status, result.Stack)
+	}
+}
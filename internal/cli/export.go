@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+	"sync"
+
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+type outputsExport struct {
+	sync.Mutex
+	outputs stratus.StackOutputs
+}
+
+func newOutputsExport() *outputsExport {
+	return &outputsExport{
+		Mutex:   sync.Mutex{},
+		outputs: make(stratus.StackOutputs),
+	}
+}
+
+func (export *outputsExport) record(stackName string, outputs map[string]string) {
+	export.Lock()
+	defer export.Unlock()
+
+	export.outputs[stackName] = outputs
+}
+
+// write saves the recorded outputs. GitHub Actions output files are appended
+// to, as the runner shares them between steps.
+func (export *outputsExport) write(path, format string) error {
+	export.Lock()
+	defer export.Unlock()
+
+	data, err := stratus.FormatOutputs(format, export.outputs)
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if format == stratus.OutputsFormatGitHub {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// newConfirmCancelUpdate decides whether an interrupted stack update should be
+// cancelled. With no flag, it prompts when attached to a terminal, and leaves
+// the update to continue otherwise.
+func newConfirmCancelUpdate(cancelOnInterrupt bool) func(stackName string) bool {
+	if cancelOnInterrupt {
+		return func(string) bool {
+			return true
+		}
+	}
+
+	if !isTerminal(os.Stdin) {
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+
+	// concurrent stacks take turns at the prompt
+	lock := new(sync.Mutex)
+
+	return func(stackName string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+
+		fmt.Fprintf(os.Stderr, "Cancel the update of stack '%s' and roll back? [y/N] ", stackName)
+
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+
+		answer = strings.ToLower(strings.TrimSpace(answer))
+
+		return answer == "y" || answer == "yes"
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -10,6 +10,7 @@ var (
 	nameToLogger = map[string]log.Logger{
 		"color":  log.ColourLogger,
 		"colour": log.ColourLogger,
+		"json":   log.JSONLogger,
 		"plain":  log.StandardLogger,
 	}
 
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+// outputCache only holds its own lock to find a stack's entry. Each entry has
+// a lock of its own, so stacks are described concurrently but at most once.
+type outputCache struct {
+	sync.Mutex
+	fromStackName map[string]*outputCacheEntry
+}
+
+type outputCacheEntry struct {
+	sync.Mutex
+	outputs []*cloudformation.Output
+	ok      bool
+}
+
+func newOutputCache() *outputCache {
+	return &outputCache{
+		Mutex:         sync.Mutex{},
+		fromStackName: make(map[string]*outputCacheEntry),
+	}
+}
+
+func (cache *outputCache) entry(stackName string) *outputCacheEntry {
+	cache.Lock()
+	defer cache.Unlock()
+
+	entry, ok := cache.fromStackName[stackName]
+	if !ok {
+		entry = new(outputCacheEntry)
+		cache.fromStackName[stackName] = entry
+	}
+
+	return entry
+}
+
+func (app *App) resolveOutputs(ctx context.Context, stack *config.Stack) error {
+	if app.offline || app.teardown {
+		return nil
+	}
+
+	return stack.ResolveOutputs(func(reference config.OutputReference) (string, error) {
+		outputs, err := app.describeOutputs(ctx, reference.StackName)
+		if stratus.IsStackDoesNotExistError(err) {
+			return "", &missingOutputError{err: err}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		for _, output := range outputs {
+			if aws.StringValue(output.OutputKey) == reference.OutputKey {
+				return aws.StringValue(output.OutputValue), nil
+			}
+		}
+
+		return "", &missingOutputError{
+			err: fmt.Errorf(
+				"output '%s' not found on stack '%s'",
+				reference.OutputKey,
+				reference.StackName,
+			),
+		}
+	})
+}
+
+// missingOutputError is an upstream stack or output that doesn't exist yet,
+// which leaves the stack's checksum unknown rather than failing status.
+type missingOutputError struct {
+	err error
+}
+
+func (err *missingOutputError) Error() string {
+	return err.err.Error()
+}
+
+func (err *missingOutputError) Unwrap() error {
+	return err.err
+}
+
+func (app *App) describeOutputs(
+	ctx context.Context,
+	stackName string,
+) ([]*cloudformation.Output, error) {
+	entry := app.outputs.entry(stackName)
+
+	entry.Lock()
+	defer entry.Unlock()
+
+	if entry.ok {
+		return entry.outputs, nil
+	}
+
+	stack, ok := app.cfg.Stacks.Find(stackName)
+	if !ok {
+		return nil, fmt.Errorf("stack '%s' not found in config", stackName)
+	}
+
+	outputs, err := app.newClient(stack).DescribeOutputs(ctx, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.outputs = outputs
+	entry.ok = true
+
+	return outputs, nil
+}
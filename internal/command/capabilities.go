@@ -0,0 +1,94 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+)
+
+// CapabilitiesError is returned when a template requires capabilities that its
+// stack does not acknowledge.
+type CapabilitiesError struct {
+	StackName string
+	Missing   []string
+	Reason    string
+}
+
+func (err *CapabilitiesError) Error() string {
+	message := fmt.Sprintf(
+		"stack '%s' template requires %s; add to capabilities or set capabilities: %s",
+		err.StackName,
+		strings.Join(err.Missing, ", "),
+		config.CapabilitiesAuto,
+	)
+
+	if err.Reason == "" {
+		return message
+	}
+
+	return fmt.Sprintf("%s (%s)", message, err.Reason)
+}
+
+// checkCapabilities fails ahead of change set creation if the template needs
+// capabilities that the stack lacks, or adopts them in auto mode.
+func checkCapabilities(
+	ctx context.Context,
+	stack *config.Stack,
+	output *cloudformation.ValidateTemplateOutput,
+) error {
+	if output == nil {
+		return nil
+	}
+
+	logger := context.Logger(ctx)
+
+	missing := make([]string, 0)
+
+	for _, capability := range aws.StringValueSlice(output.Capabilities) {
+		if !hasCapability(stack.Capabilities, capability) {
+			missing = append(missing, capability)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if stack.AutoCapabilities {
+		logger.Title("Adopt capabilities")
+
+		logger.Data(strings.Join(missing, "\n"))
+
+		stack.Capabilities = append(stack.Capabilities, missing...)
+
+		return nil
+	}
+
+	return &CapabilitiesError{
+		StackName: stack.Name,
+		Missing:   missing,
+		Reason:    aws.StringValue(output.CapabilitiesReason),
+	}
+}
+
+// hasCapability treats CAPABILITY_NAMED_IAM as covering CAPABILITY_IAM, as
+// CloudFormation does.
+func hasCapability(capabilities []string, required string) bool {
+	for _, capability := range capabilities {
+		if capability == required {
+			return true
+		}
+
+		if required == cloudformation.CapabilityCapabilityIam &&
+			capability == cloudformation.CapabilityCapabilityNamedIam {
+			return true
+		}
+	}
+
+	return false
+}
@@ -15,5 +15,10 @@ func Delete(
 
 	logger.Title("Delete stack")
 
-	return client.DeleteStack(ctx, stack)
+	err := client.DeleteStack(ctx, stack)
+	if err != nil && ctx.Err() != nil {
+		return handleInterrupt(ctx, client, stack)
+	}
+
+	return err
 }
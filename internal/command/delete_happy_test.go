@@ -56,3 +56,48 @@ func Test_Delete_Happy(t *testing.T) {
 	assert.NoError(err)
 
 }
+
+func Test_Delete_Happy_RoleARN(t *testing.T) {
+	assert := assert.New(t)
+
+	stack := &config.Stack{
+		Name:    mockStackName,
+		RoleARN: mockRoleARN,
+	}
+
+	cfn := stratus.NewCloudFormationMock()
+	defer cfn.AssertExpectations(t)
+	cfn.
+		On(
+			"DeleteStackWithContext",
+			&cloudformation.DeleteStackInput{
+				RoleARN:   aws.String(mockRoleARN),
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Return(nil, nil).
+		On(
+			"DescribeStackEventsWithContext",
+			&cloudformation.DescribeStackEventsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackEventsOutput{
+				StackEvents: make([]*cloudformation.StackEvent, 0),
+			},
+			nil,
+		).
+		On(
+			"WaitUntilStackDeleteCompleteWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Return(nil)
+
+	client := stratus.NewClient(cfn, nil)
+
+	err := command.Delete(context.Background(), client, stack)
+	assert.NoError(err)
+}
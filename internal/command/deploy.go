@@ -17,6 +17,12 @@ func Deploy(
 ) error {
 	logger := context.Logger(ctx)
 
+	// the packaged template and checksum identify the staged change set
+	err := stack.Package()
+	if err != nil {
+		return err
+	}
+
 	logger.Title("Find existing change set")
 
 	changeSet, err := client.FindExistingChangeSet(ctx, stack)
@@ -45,6 +51,16 @@ func Deploy(
 		return err
 	}
 
+	// Drift can only be detected on stacks that have finished creating
+	if stackStatus != cloudformation.StackStatusReviewInProgress && os.Getenv("CHECK_DRIFT") == "true" {
+		logger.Title("CHECK_DRIFT is true, so detecting stack drift before execution.")
+
+		err = detectDrift(ctx, client, stack)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Stack policies cannot be set on stacks that haven't finished creating
 	if stackStatus != cloudformation.StackStatusReviewInProgress {
 		logger.Title("Set stack policy")
@@ -58,9 +74,17 @@ func Deploy(
 	if stratus.IsNoopChangeSet(changeSet) {
 		logger.Title("No changes to execute.")
 	} else {
+		err = checkProtection(ctx, stack, changeSet)
+		if err != nil {
+			return err
+		}
+
 		logger.Title("Execute change set")
 
 		err = client.ExecuteChangeSet(ctx, stack, *changeSet.ChangeSetName)
+		if err != nil && ctx.Err() != nil {
+			return handleInterrupt(ctx, client, stack)
+		}
 		if err != nil {
 			return err
 		}
@@ -83,7 +107,9 @@ func Deploy(
 		return err
 	}
 
-	logger.Data(outputs)
+	logger.Data(stratus.Outputs(outputs))
+
+	context.RecordOutputs(ctx, stack.Name, stratus.Outputs(outputs).Map())
 
 	logger.Title("Update termination protection")
 
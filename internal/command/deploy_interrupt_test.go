@@ -0,0 +1,270 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/command"
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func Test_Deploy_Interrupted_CancelUpdate(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stack := &config.Stack{
+		Name: mockStackName,
+
+		Capabilities: make([]string, 0),
+		Parameters:   make(config.StackParameters, 0),
+
+		Policy:   []byte(mockStackPolicy),
+		Template: []byte(mockStackTemplate),
+
+		Checksum: mockChecksum,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var confirmed []string
+
+	ctx = context.WithConfirmCancelUpdate(ctx, func(stackName string) bool {
+		confirmed = append(confirmed, stackName)
+		return true
+	})
+
+	describeStacksOutput := func(status string) *cloudformation.DescribeStacksOutput {
+		return &cloudformation.DescribeStacksOutput{
+			Stacks: []*cloudformation.Stack{
+				&cloudformation.Stack{
+					StackStatus: aws.String(status),
+				},
+			},
+		}
+	}
+
+	cfn := stratus.NewCloudFormationMock()
+	defer cfn.AssertExpectations(t)
+	cfn.
+		On(
+			"ListChangeSetsWithContext",
+			&cloudformation.ListChangeSetsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.ListChangeSetsOutput{
+				Summaries: []*cloudformation.ChangeSetSummary{
+					&cloudformation.ChangeSetSummary{
+						ChangeSetName:   aws.String(mockChangeSetUpdateName),
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+					},
+				},
+			},
+			nil,
+		).
+		On(
+			"GetTemplateWithContext",
+			&cloudformation.GetTemplateInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				StackName:     aws.String(stack.Name),
+				TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+			},
+		).
+		Return(
+			&cloudformation.GetTemplateOutput{
+				TemplateBody: aws.String(mockStackTemplate),
+			},
+			nil,
+		).
+		On(
+			"DescribeChangeSetWithContext",
+			&cloudformation.DescribeChangeSetInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				StackName:     aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeChangeSetOutput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				Capabilities:  make([]*string, 0),
+				Parameters:    make([]*cloudformation.Parameter, 0),
+			},
+			nil,
+		).
+		On(
+			"DescribeStacksWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(describeStacksOutput(cloudformation.StackStatusUpdateComplete), nil).
+		Once().
+		On(
+			"SetStackPolicyWithContext",
+			&cloudformation.SetStackPolicyInput{
+				StackName:       aws.String(mockStackName),
+				StackPolicyBody: aws.String(mockStackPolicy),
+			},
+		).
+		Return(nil, nil).
+		On(
+			"DescribeStackEventsWithContext",
+			&cloudformation.DescribeStackEventsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackEventsOutput{
+				StackEvents: make([]*cloudformation.StackEvent, 0),
+			},
+			nil,
+		).
+		On(
+			"ExecuteChangeSetWithContext",
+			&cloudformation.ExecuteChangeSetInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				StackName:     aws.String(mockStackName),
+			},
+		).
+		Return(nil, nil).
+		On(
+			"WaitUntilStackUpdateCompleteWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Run(func(mock.Arguments) {
+			// simulate a signal arriving mid-update
+			cancel()
+		}).
+		Return(awserr.New(request.CanceledErrorCode, "waiter context canceled", nil)).
+		On(
+			"DescribeStacksWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(describeStacksOutput(cloudformation.StackStatusUpdateInProgress), nil).
+		Once().
+		On(
+			"CancelUpdateStackWithContext",
+			&cloudformation.CancelUpdateStackInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Return(new(cloudformation.CancelUpdateStackOutput), nil).
+		On(
+			"DescribeStacksWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(describeStacksOutput(cloudformation.StackStatusUpdateRollbackComplete), nil).
+		Once()
+
+	client := stratus.NewClient(cfn, nil)
+
+	err := command.Deploy(ctx, client, stack)
+	require.Error(err)
+
+	var interruptedErr *command.InterruptedError
+	require.True(errors.As(err, &interruptedErr))
+	assert.Equal(mockStackName, interruptedErr.StackName)
+	assert.Equal(cloudformation.StackStatusUpdateRollbackComplete, interruptedErr.Status)
+
+	assert.Equal([]string{mockStackName}, confirmed)
+}
+
+func Test_Delete_Interrupted(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stack := &config.Stack{
+		Name: mockStackName,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var confirmed []string
+
+	ctx = context.WithConfirmCancelUpdate(ctx, func(stackName string) bool {
+		confirmed = append(confirmed, stackName)
+		return true
+	})
+
+	cfn := stratus.NewCloudFormationMock()
+	defer cfn.AssertExpectations(t)
+	cfn.
+		On(
+			"DescribeStackEventsWithContext",
+			&cloudformation.DescribeStackEventsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackEventsOutput{
+				StackEvents: make([]*cloudformation.StackEvent, 0),
+			},
+			nil,
+		).
+		On(
+			"DeleteStackWithContext",
+			&cloudformation.DeleteStackInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Return(new(cloudformation.DeleteStackOutput), nil).
+		On(
+			"WaitUntilStackDeleteCompleteWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Run(func(mock.Arguments) {
+			// simulate a signal arriving mid-delete
+			cancel()
+		}).
+		Return(awserr.New(request.CanceledErrorCode, "waiter context canceled", nil)).
+		On(
+			"DescribeStacksWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStacksOutput{
+				Stacks: []*cloudformation.Stack{
+					&cloudformation.Stack{
+						StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+					},
+				},
+			},
+			nil,
+		)
+
+	client := stratus.NewClient(cfn, nil)
+
+	err := command.Delete(ctx, client, stack)
+	require.Error(err)
+
+	var interruptedErr *command.InterruptedError
+	require.True(errors.As(err, &interruptedErr))
+	assert.Equal(mockStackName, interruptedErr.StackName)
+	assert.Equal(cloudformation.StackStatusDeleteInProgress, interruptedErr.Status)
+
+	// deletes can't be cancelled, so there is nothing to confirm
+	assert.Empty(confirmed)
+}
@@ -568,11 +568,15 @@ func Test_Deploy_Happy_ImplicitStage(t *testing.T) {
 		On(
 			"CreateChangeSetWithContext",
 			&cloudformation.CreateChangeSetInput{
-				Capabilities:        make([]*string, 0),
-				ChangeSetName:       aws.String(mockChangeSetUpdateName),
-				ChangeSetType:       aws.String(cloudformation.ChangeSetTypeUpdate),
-				StackName:           aws.String(stack.Name),
-				Parameters:          make([]*cloudformation.Parameter, 0),
+				Capabilities:     make([]*string, 0),
+				ChangeSetName:    aws.String(mockChangeSetUpdateName),
+				ChangeSetType:    aws.String(cloudformation.ChangeSetTypeUpdate),
+				StackName:        aws.String(stack.Name),
+				NotificationARNs: make([]*string, 0),
+				Parameters:       make([]*cloudformation.Parameter, 0),
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{
+					RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+				},
 				Tags:                make([]*cloudformation.Tag, 0),
 				TemplateBody:        aws.String(string(stack.Template)),
 				UsePreviousTemplate: aws.Bool(false),
@@ -727,3 +731,235 @@ func Test_Deploy_NoImplicitStage_Fails(t *testing.T) {
 	err := command.Deploy(context.Background(), client, stack)
 	assert.Error(err)
 }
+
+func Test_Deploy_Happy_Paginated(t *testing.T) {
+	assert := assert.New(t)
+
+	stack := &config.Stack{
+		Name: mockStackName,
+
+		Capabilities:          make([]string, 0),
+		Parameters:            make(config.StackParameters, 0),
+		TerminationProtection: true,
+
+		Policy:   []byte(mockStackPolicy),
+		Template: []byte(mockStackTemplate),
+
+		Checksum: mockChecksum,
+	}
+
+	cfn := stratus.NewCloudFormationMock()
+	defer cfn.AssertExpectations(t)
+	cfn.
+		On(
+			"ListChangeSetsWithContext",
+			&cloudformation.ListChangeSetsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.ListChangeSetsOutput{
+				NextToken: aws.String("test-list-token"),
+				Summaries: []*cloudformation.ChangeSetSummary{
+					&cloudformation.ChangeSetSummary{
+						ChangeSetName:   aws.String("test-other-change-set"),
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+					},
+				},
+			},
+			nil,
+		).
+		On(
+			"ListChangeSetsWithContext",
+			&cloudformation.ListChangeSetsInput{
+				NextToken: aws.String("test-list-token"),
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.ListChangeSetsOutput{
+				Summaries: []*cloudformation.ChangeSetSummary{
+					&cloudformation.ChangeSetSummary{
+						ChangeSetName:   aws.String(mockChangeSetUpdateName),
+						ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+					},
+				},
+			},
+			nil,
+		).
+		On(
+			"GetTemplateWithContext",
+			&cloudformation.GetTemplateInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				StackName:     aws.String(stack.Name),
+				TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+			},
+		).
+		Return(
+			&cloudformation.GetTemplateOutput{
+				TemplateBody: aws.String(mockStackTemplate),
+			},
+			nil,
+		).
+		On(
+			"DescribeChangeSetWithContext",
+			&cloudformation.DescribeChangeSetInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				StackName:     aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeChangeSetOutput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				Capabilities:  make([]*string, 0),
+				Changes: []*cloudformation.Change{
+					&cloudformation.Change{
+						ResourceChange: &cloudformation.ResourceChange{
+							Action:            aws.String(cloudformation.ChangeActionModify),
+							LogicalResourceId: aws.String("test-resource-a"),
+						},
+					},
+				},
+				NextToken:  aws.String("test-describe-token"),
+				Parameters: make([]*cloudformation.Parameter, 0),
+			},
+			nil,
+		).
+		On(
+			"DescribeChangeSetWithContext",
+			&cloudformation.DescribeChangeSetInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				NextToken:     aws.String("test-describe-token"),
+				StackName:     aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeChangeSetOutput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				Capabilities:  make([]*string, 0),
+				Changes: []*cloudformation.Change{
+					&cloudformation.Change{
+						ResourceChange: &cloudformation.ResourceChange{
+							Action:            aws.String(cloudformation.ChangeActionModify),
+							LogicalResourceId: aws.String("test-resource-b"),
+						},
+					},
+				},
+				Parameters: make([]*cloudformation.Parameter, 0),
+			},
+			nil,
+		).
+		On(
+			"ExecuteChangeSetWithContext",
+			&cloudformation.ExecuteChangeSetInput{
+				ChangeSetName: aws.String(mockChangeSetUpdateName),
+				StackName:     aws.String(mockStackName),
+			},
+		).
+		Return(nil, nil).
+		On(
+			"WaitUntilStackUpdateCompleteWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Return(nil).
+		On(
+			"DescribeStackEventsWithContext",
+			&cloudformation.DescribeStackEventsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackEventsOutput{
+				NextToken: aws.String("test-events-token"),
+				StackEvents: []*cloudformation.StackEvent{
+					&cloudformation.StackEvent{
+						EventId: aws.String("test-event-b"),
+					},
+				},
+			},
+			nil,
+		).
+		Once().
+		On(
+			"DescribeStackEventsWithContext",
+			&cloudformation.DescribeStackEventsInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackEventsOutput{
+				NextToken: aws.String("test-events-token"),
+				StackEvents: []*cloudformation.StackEvent{
+					&cloudformation.StackEvent{
+						EventId:           aws.String("test-event-c"),
+						LogicalResourceId: aws.String(mockStackName),
+						ResourceStatus:    aws.String(cloudformation.ResourceStatusUpdateComplete),
+						ResourceType:      aws.String("AWS::CloudFormation::Stack"),
+					},
+				},
+			},
+			nil,
+		).
+		On(
+			"DescribeStackEventsWithContext",
+			&cloudformation.DescribeStackEventsInput{
+				NextToken: aws.String("test-events-token"),
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackEventsOutput{
+				StackEvents: []*cloudformation.StackEvent{
+					&cloudformation.StackEvent{
+						EventId: aws.String("test-event-b"),
+					},
+				},
+			},
+			nil,
+		).
+		On(
+			"DescribeStacksWithContext",
+			&cloudformation.DescribeStacksInput{
+				StackName: aws.String(stack.Name),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStacksOutput{
+				Stacks: []*cloudformation.Stack{
+					&cloudformation.Stack{
+						StackStatus: aws.String(cloudformation.StackStatusUpdateComplete),
+						Outputs:     make([]*cloudformation.Output, 0),
+					},
+				},
+			},
+			nil,
+		).
+		On(
+			"SetStackPolicyWithContext",
+			&cloudformation.SetStackPolicyInput{
+				StackName:       aws.String(mockStackName),
+				StackPolicyBody: aws.String(mockStackPolicy),
+			},
+		).
+		Return(nil, nil).
+		On(
+			"UpdateTerminationProtectionWithContext",
+			&cloudformation.UpdateTerminationProtectionInput{
+				EnableTerminationProtection: aws.Bool(true),
+				StackName:                   aws.String(mockStackName),
+			},
+		).
+		Return(nil, nil)
+
+	client := stratus.NewClient(cfn, nil)
+
+	changeSet, err := client.FindExistingChangeSet(context.Background(), stack)
+	assert.NoError(err)
+	assert.Len(changeSet.Changes, 2)
+	assert.Nil(changeSet.NextToken)
+
+	err = command.Deploy(context.Background(), client, stack)
+	assert.NoError(err)
+}
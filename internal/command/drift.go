@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+// DriftError is returned when a stack's resources no longer match its
+// template.
+type DriftError struct {
+	StackName string
+}
+
+func (err *DriftError) Error() string {
+	return fmt.Sprintf("stack '%s' has drifted from its template", err.StackName)
+}
+
+func Drift(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	logger := context.Logger(ctx)
+
+	logger.Title("Detect stack drift")
+
+	return detectDrift(ctx, client, stack)
+}
+
+func detectDrift(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	logger := context.Logger(ctx)
+
+	drift, err := client.DetectDrift(ctx, stack)
+	if err != nil && ctx.Err() != nil {
+		return handleDriftInterrupt(ctx, client, stack)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Data(drift)
+
+	if drift.HasDrift() {
+		return &DriftError{
+			StackName: stack.Name,
+		}
+	}
+
+	return nil
+}
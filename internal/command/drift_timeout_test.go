@@ -0,0 +1,74 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/command"
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func Test_Drift_Timeout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stack := &config.Stack{
+		Name:         mockStackName,
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      1 * time.Hour,
+	}
+
+	cfn := stratus.NewCloudFormationMock()
+	defer cfn.AssertExpectations(t)
+	cfn.
+		On(
+			"DetectStackDriftWithContext",
+			&cloudformation.DetectStackDriftInput{
+				StackName: aws.String(mockStackName),
+			},
+		).
+		Return(
+			&cloudformation.DetectStackDriftOutput{
+				StackDriftDetectionId: aws.String("mock-detection-id"),
+			},
+			nil,
+		).
+		On(
+			"DescribeStackDriftDetectionStatusWithContext",
+			&cloudformation.DescribeStackDriftDetectionStatusInput{
+				StackDriftDetectionId: aws.String("mock-detection-id"),
+			},
+		).
+		Return(
+			&cloudformation.DescribeStackDriftDetectionStatusOutput{
+				DetectionStatus: aws.String(cloudformation.StackDriftDetectionStatusDetectionInProgress),
+			},
+			nil,
+		)
+
+	client := stratus.NewClient(cfn, nil)
+
+	// the CLI timeout takes precedence over the stack's
+	ctx := context.WithWaitTimeout(context.Background(), 50*time.Millisecond)
+
+	err := command.Drift(ctx, client, stack)
+	require.Error(err)
+
+	var timeoutErr *stratus.TimeoutError
+	require.True(errors.As(err, &timeoutErr))
+	assert.Equal(mockStackName, timeoutErr.StackName)
+	assert.Equal(cloudformation.StackDriftDetectionStatusDetectionInProgress, timeoutErr.Status)
+	assert.Equal(50*time.Millisecond, timeoutErr.Timeout)
+	assert.Equal(
+		"stack 'test-stack-name' timed out after 50ms with status DETECTION_IN_PROGRESS",
+		err.Error(),
+	)
+}
@@ -0,0 +1,621 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/command"
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+const (
+	fakeStackPolicy = `{"Statement":[{"Effect":"Allow","Action":"Update:*","Principal":"*","Resource":"*"}]}`
+
+	fakeStackTemplateV1 = `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+Outputs:
+  BucketName:
+    Value: fake-bucket-name
+`
+
+	fakeStackTemplateV2 = `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      VersioningConfiguration:
+        Status: Enabled
+  Topic:
+    Type: AWS::SNS::Topic
+`
+)
+
+func newFakeStack(t *testing.T, template string) *config.Stack {
+	stack := &config.Stack{
+		Name: mockStackName,
+
+		Capabilities: make([]string, 0),
+		Parameters:   make(config.StackParameters, 0),
+		Tags:         make(config.StackTags, 0),
+
+		Policy:   []byte(fakeStackPolicy),
+		Template: []byte(template),
+	}
+
+	checksum, err := config.CalculateChecksum(stack.Hashable())
+	require.NoError(t, err)
+
+	stack.Checksum = checksum
+
+	return stack
+}
+
+func describeFakeStack(
+	t *testing.T,
+	cfn *stratus.CloudFormationFake,
+) *cloudformation.Stack {
+	output, err := cfn.DescribeStacksWithContext(
+		context.Background(),
+		&cloudformation.DescribeStacksInput{
+			StackName: aws.String(mockStackName),
+		},
+	)
+	require.NoError(t, err)
+
+	return output.Stacks[0]
+}
+
+func Test_Fake_Lifecycle(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	// create
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+
+	diff, changeSet, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+	assert.True(diff.HasChangeSet())
+	assert.Equal(mockStackName, *changeSet.StackName)
+	require.Len(changeSet.Changes, 1)
+	assert.Equal(cloudformation.ChangeActionAdd, *changeSet.Changes[0].ResourceChange.Action)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	description := describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusCreateComplete, *description.StackStatus)
+	require.Len(description.Outputs, 1)
+	assert.Equal("fake-bucket-name", *description.Outputs[0].OutputValue)
+
+	// no-op update
+
+	diff, changeSet, err = command.Stage(ctx, client, stack)
+	require.NoError(err)
+	assert.False(diff.HasChangeSet())
+	assert.Nil(changeSet)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	description = describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusCreateComplete, *description.StackStatus)
+
+	// update
+
+	stack = newFakeStack(t, fakeStackTemplateV2)
+
+	_, changeSet, err = command.Stage(ctx, client, stack)
+	require.NoError(err)
+	require.Len(changeSet.Changes, 2)
+	assert.Equal(cloudformation.ChangeActionModify, *changeSet.Changes[0].ResourceChange.Action)
+	assert.Equal(cloudformation.ChangeActionAdd, *changeSet.Changes[1].ResourceChange.Action)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	description = describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusUpdateComplete, *description.StackStatus)
+	assert.Empty(description.Outputs)
+
+	// delete
+
+	err = command.Delete(ctx, client, stack)
+	require.NoError(err)
+
+	_, err = client.GetStackStatus(ctx, stack)
+	require.Error(err)
+	assert.Contains(err.Error(), "does not exist")
+}
+
+func Test_Fake_TerminationProtection(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	s3 := stratus.NewS3Fake()
+	cfn := stratus.NewCloudFormationFake(s3)
+	client := stratus.NewClient(cfn, s3)
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+	stack.TerminationProtection = true
+	stack.ArtefactBucket = mockArtefactBucket
+	stack.PolicyKey = mockStackPolicyKey
+	stack.TemplateKey = mockStackTemplateKey
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	template, ok := s3.Object(mockArtefactBucket, mockStackTemplateKey)
+	assert.True(ok)
+	assert.Equal(fakeStackTemplateV1, string(template))
+
+	description := describeFakeStack(t, cfn)
+	assert.True(*description.EnableTerminationProtection)
+
+	err = command.Delete(ctx, client, stack)
+	require.Error(err)
+	assert.Contains(err.Error(), "TerminationProtection is enabled")
+}
+
+func Test_Fake_Capabilities(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	template := `
+Resources:
+  Role:
+    Type: AWS::IAM::Role
+    Properties:
+      RoleName: fake-role
+`
+
+	stack := newFakeStack(t, template)
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.Error(err)
+
+	var capabilitiesErr *command.CapabilitiesError
+	require.True(errors.As(err, &capabilitiesErr))
+	assert.Equal([]string{cloudformation.CapabilityCapabilityNamedIam}, capabilitiesErr.Missing)
+	assert.Contains(err.Error(), "[Role]")
+
+	stack.AutoCapabilities = true
+
+	_, changeSet, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+	assert.Equal([]string{cloudformation.CapabilityCapabilityNamedIam}, stack.Capabilities)
+	assert.Equal(stack.Capabilities, aws.StringValueSlice(changeSet.Capabilities))
+
+	// deploy runs separately from stage, against a freshly loaded config
+
+	stack = newFakeStack(t, template)
+	stack.AutoCapabilities = true
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	description := describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusCreateComplete, *description.StackStatus)
+}
+
+func Test_Fake_Parameters(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	template := `
+Parameters:
+  Environment:
+    Type: String
+  LogLevel:
+    Type: String
+    Default: info
+  Password:
+    Type: String
+    NoEcho: true
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+`
+
+	stack := newFakeStack(t, template)
+	stack.Parameters = config.StackParameters{
+		{Key: "Enviroment", Value: "prod"},
+		{Key: "Password", Value: "fake-password"},
+	}
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.Error(err)
+
+	var parametersErr *command.ParametersError
+	require.True(errors.As(err, &parametersErr))
+	assert.Equal(
+		[]string{
+			"'Enviroment' is not declared by the template",
+			"'Environment' has no default value and is not set",
+		},
+		parametersErr.Problems,
+	)
+	assert.True(stack.Parameters[1].Sensitive)
+
+	stack.Parameters[0].Key = "Environment"
+
+	_, _, err = command.Stage(ctx, client, stack)
+	require.NoError(err)
+}
+
+func Test_Fake_StackSettings(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	const (
+		alarm = "arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:fake"
+		topic = "arn:aws:sns:ap-southeast-2:000000000000:fake"
+	)
+
+	withSettings := newFakeStack(t, fakeStackTemplateV1)
+	withSettings.NotificationARNs = []string{topic}
+	withSettings.RollbackTriggers = &config.StackRollbackTriggers{
+		AlarmARNs:         []string{alarm},
+		MonitoringMinutes: 5,
+	}
+
+	checksum, err := config.CalculateChecksum(withSettings.Hashable())
+	require.NoError(err)
+	withSettings.Checksum = checksum
+
+	_, _, err = command.Stage(ctx, client, withSettings)
+	require.NoError(err)
+
+	err = command.Deploy(ctx, client, withSettings)
+	require.NoError(err)
+
+	description := describeFakeStack(t, cfn)
+	assert.Equal([]string{topic}, aws.StringValueSlice(description.NotificationARNs))
+	require.NotNil(description.RollbackConfiguration)
+	require.Len(description.RollbackConfiguration.RollbackTriggers, 1)
+	assert.Equal(alarm, *description.RollbackConfiguration.RollbackTriggers[0].Arn)
+
+	// removing the settings is a change, rather than keeping the previous values
+
+	withoutSettings := newFakeStack(t, fakeStackTemplateV1)
+
+	diff, changeSet, err := command.Stage(ctx, client, withoutSettings)
+	require.NoError(err)
+	assert.True(diff.HasChangeSet())
+	assert.Empty(changeSet.NotificationARNs)
+	assert.Nil(changeSet.RollbackConfiguration)
+
+	err = command.Deploy(ctx, client, withoutSettings)
+	require.NoError(err)
+
+	description = describeFakeStack(t, cfn)
+	assert.Empty(description.NotificationARNs)
+	assert.Nil(description.RollbackConfiguration)
+
+	diff, _, err = command.Stage(ctx, client, withoutSettings)
+	require.NoError(err)
+	assert.False(diff.HasChangeSet())
+}
+
+func Test_Fake_Drift(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	err = command.Drift(ctx, client, stack)
+	require.NoError(err)
+
+	err = cfn.SetResourceDrifts(
+		mockStackName,
+		&cloudformation.StackResourceDrift{
+			LogicalResourceId: aws.String("Bucket"),
+			PropertyDifferences: []*cloudformation.PropertyDifference{
+				{
+					ActualValue:    aws.String("Suspended"),
+					DifferenceType: aws.String(cloudformation.DifferenceTypeNotEqual),
+					ExpectedValue:  aws.String("Enabled"),
+					PropertyPath:   aws.String("/VersioningConfiguration/Status"),
+				},
+			},
+			ResourceType:             aws.String("AWS::S3::Bucket"),
+			StackResourceDriftStatus: aws.String(cloudformation.StackResourceDriftStatusModified),
+		},
+	)
+	require.NoError(err)
+
+	err = command.Drift(ctx, client, stack)
+	require.Error(err)
+
+	var driftErr *command.DriftError
+	require.True(errors.As(err, &driftErr))
+	assert.Equal(mockStackName, driftErr.StackName)
+
+	t.Setenv("CHECK_DRIFT", "true")
+	t.Setenv("FORCE_DEPLOY", "true")
+
+	err = command.Deploy(ctx, client, newFakeStack(t, fakeStackTemplateV2))
+	require.True(errors.As(err, &driftErr))
+
+	description := describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusCreateComplete, *description.StackStatus)
+}
+
+func Test_Fake_Protection(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	// remove the bucket
+	stack = newFakeStack(t, "Resources: {}")
+	stack.Protect = []*config.StackProtection{
+		{
+			ResourceType: "AWS::S3::*",
+			Allow:        config.DefaultProtectionAllow,
+		},
+	}
+
+	_, _, err = command.Stage(ctx, client, stack)
+	require.Error(err)
+
+	var protectionErr *command.ProtectionError
+	require.True(errors.As(err, &protectionErr))
+	require.Len(protectionErr.Violations, 1)
+	assert.Equal(cloudformation.ChangeActionRemove, protectionErr.Violations[0].Action)
+	assert.Equal("Bucket", protectionErr.Violations[0].LogicalID)
+
+	err = command.Deploy(ctx, client, stack)
+	require.True(errors.As(err, &protectionErr))
+
+	description := describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusCreateComplete, *description.StackStatus)
+
+	stack.AcknowledgeDestructive = []string{"Bucket"}
+
+	_, _, err = command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	stack.AcknowledgeDestructive = nil
+
+	err = command.Deploy(context.WithAllowDestructive(ctx, true), client, stack)
+	require.NoError(err)
+
+	description = describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusUpdateComplete, *description.StackStatus)
+}
+
+func Test_Fake_Recover(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	// failed update rollback is continued by recover
+
+	err = cfn.SetStackStatus(mockStackName, cloudformation.StackStatusUpdateRollbackFailed)
+	require.NoError(err)
+
+	err = command.Recover(context.WithResourcesToSkip(ctx, []string{"Bucket"}), client, stack)
+	require.NoError(err)
+
+	description := describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusUpdateRollbackComplete, *description.StackStatus)
+
+	// stage stops on a failed update rollback without --auto-recover
+
+	err = cfn.SetStackStatus(mockStackName, cloudformation.StackStatusUpdateRollbackFailed)
+	require.NoError(err)
+
+	stack = newFakeStack(t, fakeStackTemplateV2)
+
+	_, _, err = command.Stage(ctx, client, stack)
+	require.Error(err)
+
+	var blocked *stratus.RecoverableStatusError
+	require.True(errors.As(err, &blocked), err.Error())
+	assert.Equal(cloudformation.StackStatusUpdateRollbackFailed, blocked.Status)
+
+	description = describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusUpdateRollbackFailed, *description.StackStatus)
+
+	// failed update rollback is continued by stage with --auto-recover
+
+	autoRecoverCtx := context.WithAutoRecover(ctx, true)
+
+	_, changeSet, err := command.Stage(autoRecoverCtx, client, stack)
+	require.NoError(err)
+	require.NotNil(changeSet)
+	assert.Contains(*changeSet.ChangeSetName, "stratus-update-")
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	description = describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusUpdateComplete, *description.StackStatus)
+
+	// failed create is deleted by stage with --auto-recover, then recreated
+
+	err = cfn.SetStackStatus(mockStackName, cloudformation.StackStatusRollbackComplete)
+	require.NoError(err)
+
+	stack = newFakeStack(t, fakeStackTemplateV1)
+
+	_, _, err = command.Stage(ctx, client, stack)
+	require.True(errors.As(err, &blocked))
+	assert.Equal(cloudformation.StackStatusRollbackComplete, blocked.Status)
+
+	_, changeSet, err = command.Stage(autoRecoverCtx, client, stack)
+	require.NoError(err)
+	require.NotNil(changeSet)
+	assert.Contains(*changeSet.ChangeSetName, "stratus-create-")
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	description = describeFakeStack(t, cfn)
+	assert.Equal(cloudformation.StackStatusCreateComplete, *description.StackStatus)
+
+	// failed create is deleted by recover
+
+	err = cfn.SetStackStatus(mockStackName, cloudformation.StackStatusRollbackComplete)
+	require.NoError(err)
+
+	err = command.Recover(ctx, client, stack)
+	require.NoError(err)
+
+	_, err = client.GetStackStatus(ctx, stack)
+	require.Error(err)
+	assert.Contains(err.Error(), "does not exist")
+}
+
+func Test_Fake_Status(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+
+	summary, err := client.DescribeSummary(ctx, stack)
+	require.NoError(err)
+	assert.Equal(&stratus.StackSummary{Name: mockStackName}, summary)
+
+	_, _, err = command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	summary, err = client.DescribeSummary(ctx, stack)
+	require.NoError(err)
+	assert.True(summary.Exists)
+	assert.Equal(cloudformation.StackStatusReviewInProgress, summary.StackStatus)
+	assert.True(summary.PendingChangeSet)
+	assert.False(summary.ChecksumMatches)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	summary, err = client.DescribeSummary(ctx, stack)
+	require.NoError(err)
+	assert.Equal(cloudformation.StackStatusCreateComplete, summary.StackStatus)
+	assert.NotNil(summary.LastUpdatedTime)
+	assert.False(summary.PendingChangeSet)
+	assert.True(summary.ChecksumMatches)
+
+	summary, err = client.DescribeSummary(ctx, newFakeStack(t, fakeStackTemplateV2))
+	require.NoError(err)
+	assert.False(summary.ChecksumMatches)
+
+	newClient := func(*config.Stack) *stratus.Client { return client }
+
+	err = command.Status(ctx, newClient, config.Stacks{stack})
+	require.NoError(err)
+}
+
+func Test_Fake_Outputs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	recorded := make(map[string]map[string]string)
+
+	ctx := context.WithOutputsRecorder(
+		context.Background(),
+		func(stackName string, outputs map[string]string) {
+			recorded[stackName] = outputs
+		},
+	)
+
+	cfn := stratus.NewCloudFormationFake(nil)
+	client := stratus.NewClient(cfn, nil)
+
+	stack := newFakeStack(t, fakeStackTemplateV1)
+
+	_, _, err := command.Stage(ctx, client, stack)
+	require.NoError(err)
+
+	err = command.Deploy(ctx, client, stack)
+	require.NoError(err)
+
+	expected := map[string]map[string]string{
+		mockStackName: {
+			"BucketName": "fake-bucket-name",
+		},
+	}
+
+	assert.Equal(expected, recorded)
+
+	recorded = make(map[string]map[string]string)
+
+	err = command.Outputs(ctx, client, stack)
+	require.NoError(err)
+	assert.Equal(expected, recorded)
+}
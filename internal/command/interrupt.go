@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+// InterruptedError is returned when a signal stops Stratus while a stack
+// operation is in progress.
+type InterruptedError struct {
+	StackName string
+	Status    string
+}
+
+func (err *InterruptedError) Error() string {
+	return fmt.Sprintf("stack '%s' was interrupted with status %s", err.StackName, err.Status)
+}
+
+// handleInterrupt offers to cancel a stack update that was in progress when
+// the context was cancelled, and follows the rollback through to the end. Other
+// stack operations, like deletes and rollbacks, can't be cancelled and carry
+// on in CloudFormation.
+func handleInterrupt(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	// the context is already cancelled, but cleanup still needs to call AWS
+	ctx = context.WithoutCancel(ctx)
+
+	logger := context.Logger(ctx)
+
+	status, err := client.GetStackStatus(ctx, stack)
+	if stratus.IsStackDoesNotExistError(err) {
+		status, err = cloudformation.StackStatusDeleteComplete, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if status != cloudformation.StackStatusUpdateInProgress {
+		logger.Title("Interrupted with stack status %s, which cannot be cancelled.", status)
+
+		return &InterruptedError{StackName: stack.Name, Status: status}
+	}
+
+	if !context.ConfirmCancelUpdate(ctx, stack.Name) {
+		logger.Title("Interrupted, leaving the stack update to continue in CloudFormation.")
+
+		return &InterruptedError{StackName: stack.Name, Status: status}
+	}
+
+	logger.Title("Interrupted, so cancelling the stack update")
+
+	status, err = client.CancelUpdate(ctx, stack)
+	if err != nil {
+		return err
+	}
+
+	return &InterruptedError{StackName: stack.Name, Status: status}
+}
+
+// handleDriftInterrupt reports drift detection that was in progress when the
+// context was cancelled. Detection can't be cancelled and finishes in
+// CloudFormation without changing the stack.
+func handleDriftInterrupt(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	ctx = context.WithoutCancel(ctx)
+
+	logger := context.Logger(ctx)
+
+	status, err := client.GetStackStatus(ctx, stack)
+	if err != nil {
+		return err
+	}
+
+	logger.Title("Interrupted, leaving drift detection to finish in CloudFormation.")
+
+	return &InterruptedError{StackName: stack.Name, Status: status}
+}
@@ -8,6 +8,7 @@ const (
 	mockChecksum = "1000000000200000000030000000004000000000500000000060000000007000"
 
 	mockArtefactBucket   = "test-bucket-name"
+	mockRoleARN          = "arn:aws:iam::000000000000:role/test-role-name"
 	mockStackPolicyKey   = "test-policy-key.json"
 	mockStackTemplateKey = "test-template-key.yaml"
 
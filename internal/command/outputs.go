@@ -0,0 +1,28 @@
+package command
+
+import (
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func Outputs(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	logger := context.Logger(ctx)
+
+	logger.Title("Describe outputs")
+
+	outputs, err := client.DescribeOutputs(ctx, stack)
+	if err != nil {
+		return err
+	}
+
+	logger.Data(stratus.Outputs(outputs))
+
+	context.RecordOutputs(ctx, stack.Name, stratus.Outputs(outputs).Map())
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+)
+
+// ParametersError is returned when a stack's parameters don't line up with
+// those that its template declares. It lists every problem at once.
+type ParametersError struct {
+	StackName string
+	Problems  []string
+}
+
+func (err *ParametersError) Error() string {
+	return fmt.Sprintf(
+		"stack '%s' has %d parameter problem(s):\n%s",
+		err.StackName,
+		len(err.Problems),
+		strings.Join(err.Problems, "\n"),
+	)
+}
+
+// checkParameters fails ahead of change set creation on parameters that the
+// template doesn't declare, and on declared parameters without a default that
+// aren't set. NoEcho parameters are flagged, as their values are masked.
+func checkParameters(
+	ctx context.Context,
+	stack *config.Stack,
+	output *cloudformation.ValidateTemplateOutput,
+) error {
+	if output == nil {
+		return nil
+	}
+
+	logger := context.Logger(ctx)
+
+	declared := make(map[string]*cloudformation.TemplateParameter, len(output.Parameters))
+
+	for _, parameter := range output.Parameters {
+		declared[aws.StringValue(parameter.ParameterKey)] = parameter
+	}
+
+	configured := make(map[string]struct{}, len(stack.Parameters))
+
+	problems := make([]string, 0)
+
+	for _, parameter := range stack.Parameters {
+		configured[parameter.Key] = struct{}{}
+
+		if _, ok := declared[parameter.Key]; !ok {
+			problems = append(problems, fmt.Sprintf("'%s' is not declared by the template", parameter.Key))
+		}
+	}
+
+	noEcho := make([]string, 0)
+
+	for _, parameter := range output.Parameters {
+		key := aws.StringValue(parameter.ParameterKey)
+
+		if _, ok := configured[key]; !ok && parameter.DefaultValue == nil {
+			problems = append(problems, fmt.Sprintf("'%s' has no default value and is not set", key))
+		}
+
+		if aws.BoolValue(parameter.NoEcho) {
+			noEcho = append(noEcho, fmt.Sprintf("'%s' is NoEcho, so its value is masked", key))
+		}
+	}
+
+	if len(noEcho) != 0 {
+		logger.Title("NoEcho parameters")
+
+		logger.Data(strings.Join(noEcho, "\n"))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ParametersError{
+		StackName: stack.Name,
+		Problems:  problems,
+	}
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+// ProtectionError is returned when a change set contains changes that a
+// stack's protections do not allow.
+type ProtectionError struct {
+	StackName  string
+	Violations stratus.ProtectionViolations
+}
+
+func (err *ProtectionError) Error() string {
+	return fmt.Sprintf(
+		"stack '%s' has %d change(s) blocked by its protections; list them under acknowledgeDestructive or pass --allow-destructive",
+		err.StackName,
+		len(err.Violations),
+	)
+}
+
+func checkProtection(
+	ctx context.Context,
+	stack *config.Stack,
+	changeSet *cloudformation.DescribeChangeSetOutput,
+) error {
+	logger := context.Logger(ctx)
+
+	violations := stratus.FindProtectionViolations(stack, changeSet)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	logger.Title("Protected resource changes")
+
+	logger.Data(violations)
+
+	if context.AllowDestructive(ctx) {
+		logger.Data("--allow-destructive is set, so continuing.")
+		return nil
+	}
+
+	return &ProtectionError{
+		StackName:  stack.Name,
+		Violations: violations,
+	}
+}
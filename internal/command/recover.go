@@ -0,0 +1,29 @@
+package command
+
+import (
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func Recover(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+) error {
+	logger := context.Logger(ctx)
+
+	logger.Title("Recover stack")
+
+	status, err := client.Recover(ctx, stack)
+	if err != nil && ctx.Err() != nil {
+		return handleInterrupt(ctx, client, stack)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Title("Stack status is %s.", status)
+
+	return nil
+}
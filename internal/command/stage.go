@@ -1,9 +1,12 @@
 package command
 
 import (
+	"errors"
+
 	"github.com/72636c/stratus/internal/config"
 	"github.com/72636c/stratus/internal/context"
 	"github.com/72636c/stratus/internal/stratus"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 )
 
@@ -14,6 +17,11 @@ func Stage(
 ) (*stratus.Diff, *cloudformation.DescribeChangeSetOutput, error) {
 	logger := context.Logger(ctx)
 
+	err := stack.Package()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	logger.Title("Validate template")
 
 	validateOutput, err := client.ValidateTemplate(ctx, stack)
@@ -21,8 +29,20 @@ func Stage(
 		return nil, nil, err
 	}
 
+	stack.Parameters.MarkSensitive(toNoEchoParameterKeys(validateOutput)...)
+
 	logger.Data(validateOutput)
 
+	err = checkCapabilities(ctx, stack, validateOutput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkParameters(ctx, stack, validateOutput)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if stack.ShouldUpload() {
 		logger.Title("Upload artefacts")
 
@@ -36,7 +56,10 @@ func Stage(
 
 	describeOutput, err := client.CreateChangeSet(ctx, stack)
 	if err != nil {
-		return nil, nil, err
+		describeOutput, err = recoverAndCreateChangeSet(ctx, client, stack, err)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	logger.Title("Diff stack")
@@ -48,5 +71,55 @@ func Stage(
 
 	logger.Data(diffOutput)
 
+	err = checkProtection(ctx, stack, describeOutput)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return diffOutput, describeOutput, nil
 }
+
+// recoverAndCreateChangeSet recovers a stack that blocked its change set and
+// tries again, if --auto-recover is set.
+func recoverAndCreateChangeSet(
+	ctx context.Context,
+	client *stratus.Client,
+	stack *config.Stack,
+	cause error,
+) (*cloudformation.DescribeChangeSetOutput, error) {
+	logger := context.Logger(ctx)
+
+	var blocked *stratus.RecoverableStatusError
+	if !errors.As(cause, &blocked) || !context.AutoRecover(ctx) {
+		return nil, cause
+	}
+
+	logger.Title("Recover stack in %s", blocked.Status)
+
+	logger.Data("--auto-recover is set, so recovering the stack before creating a change set.")
+
+	_, err := client.Recover(ctx, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Title("Create change set")
+
+	return client.CreateChangeSet(ctx, stack)
+}
+
+func toNoEchoParameterKeys(output *cloudformation.ValidateTemplateOutput) []string {
+	keys := make([]string, 0)
+
+	if output == nil {
+		return keys
+	}
+
+	for _, parameter := range output.Parameters {
+		if aws.BoolValue(parameter.NoEcho) {
+			keys = append(keys, aws.StringValue(parameter.ParameterKey))
+		}
+	}
+
+	return keys
+}
@@ -44,11 +44,15 @@ func Test_Stage_Happy_CreateChangeSet(t *testing.T) {
 		On(
 			"CreateChangeSetWithContext",
 			&cloudformation.CreateChangeSetInput{
-				Capabilities:        make([]*string, 0),
-				ChangeSetName:       aws.String(mockChangeSetUpdateName),
-				ChangeSetType:       aws.String(cloudformation.ChangeSetTypeUpdate),
-				StackName:           aws.String(stack.Name),
-				Parameters:          make([]*cloudformation.Parameter, 0),
+				Capabilities:     make([]*string, 0),
+				ChangeSetName:    aws.String(mockChangeSetUpdateName),
+				ChangeSetType:    aws.String(cloudformation.ChangeSetTypeUpdate),
+				StackName:        aws.String(stack.Name),
+				NotificationARNs: make([]*string, 0),
+				Parameters:       make([]*cloudformation.Parameter, 0),
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{
+					RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+				},
 				Tags:                make([]*cloudformation.Tag, 0),
 				TemplateBody:        aws.String(string(stack.Template)),
 				UsePreviousTemplate: aws.Bool(false),
@@ -58,11 +62,15 @@ func Test_Stage_Happy_CreateChangeSet(t *testing.T) {
 		On(
 			"CreateChangeSetWithContext",
 			&cloudformation.CreateChangeSetInput{
-				Capabilities:        make([]*string, 0),
-				ChangeSetName:       aws.String(mockChangeSetCreateName),
-				ChangeSetType:       aws.String(cloudformation.ChangeSetTypeCreate),
-				StackName:           aws.String(stack.Name),
-				Parameters:          make([]*cloudformation.Parameter, 0),
+				Capabilities:     make([]*string, 0),
+				ChangeSetName:    aws.String(mockChangeSetCreateName),
+				ChangeSetType:    aws.String(cloudformation.ChangeSetTypeCreate),
+				StackName:        aws.String(stack.Name),
+				NotificationARNs: make([]*string, 0),
+				Parameters:       make([]*cloudformation.Parameter, 0),
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{
+					RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+				},
 				Tags:                make([]*cloudformation.Tag, 0),
 				TemplateBody:        aws.String(string(stack.Template)),
 				UsePreviousTemplate: aws.Bool(false),
@@ -140,11 +148,15 @@ func Test_Stage_Happy_NoopChangeSet(t *testing.T) {
 		On(
 			"CreateChangeSetWithContext",
 			&cloudformation.CreateChangeSetInput{
-				Capabilities:        make([]*string, 0),
-				ChangeSetName:       aws.String(mockChangeSetUpdateName),
-				ChangeSetType:       aws.String(cloudformation.ChangeSetTypeUpdate),
-				StackName:           aws.String(stack.Name),
-				Parameters:          make([]*cloudformation.Parameter, 0),
+				Capabilities:     make([]*string, 0),
+				ChangeSetName:    aws.String(mockChangeSetUpdateName),
+				ChangeSetType:    aws.String(cloudformation.ChangeSetTypeUpdate),
+				StackName:        aws.String(stack.Name),
+				NotificationARNs: make([]*string, 0),
+				Parameters:       make([]*cloudformation.Parameter, 0),
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{
+					RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+				},
 				Tags:                make([]*cloudformation.Tag, 0),
 				TemplateBody:        aws.String(string(stack.Template)),
 				UsePreviousTemplate: aws.Bool(false),
@@ -233,11 +245,15 @@ func Test_Stage_Happy_NoopChangeSet_UploadArtefacts(t *testing.T) {
 		On(
 			"CreateChangeSetWithContext",
 			&cloudformation.CreateChangeSetInput{
-				Capabilities:        make([]*string, 0),
-				ChangeSetName:       aws.String(mockChangeSetUpdateName),
-				ChangeSetType:       aws.String(cloudformation.ChangeSetTypeUpdate),
-				StackName:           aws.String(stack.Name),
-				Parameters:          make([]*cloudformation.Parameter, 0),
+				Capabilities:     make([]*string, 0),
+				ChangeSetName:    aws.String(mockChangeSetUpdateName),
+				ChangeSetType:    aws.String(cloudformation.ChangeSetTypeUpdate),
+				StackName:        aws.String(stack.Name),
+				NotificationARNs: make([]*string, 0),
+				Parameters:       make([]*cloudformation.Parameter, 0),
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{
+					RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+				},
 				Tags:                make([]*cloudformation.Tag, 0),
 				TemplateURL:         aws.String(mockStackTemplateURL),
 				UsePreviousTemplate: aws.Bool(false),
@@ -352,11 +368,15 @@ func Test_Stage_Happy_UpdateChangeSet(t *testing.T) {
 		On(
 			"CreateChangeSetWithContext",
 			&cloudformation.CreateChangeSetInput{
-				Capabilities:        make([]*string, 0),
-				ChangeSetName:       aws.String(mockChangeSetUpdateName),
-				ChangeSetType:       aws.String(cloudformation.ChangeSetTypeUpdate),
-				StackName:           aws.String(stack.Name),
-				Parameters:          make([]*cloudformation.Parameter, 0),
+				Capabilities:     make([]*string, 0),
+				ChangeSetName:    aws.String(mockChangeSetUpdateName),
+				ChangeSetType:    aws.String(cloudformation.ChangeSetTypeUpdate),
+				StackName:        aws.String(stack.Name),
+				NotificationARNs: make([]*string, 0),
+				Parameters:       make([]*cloudformation.Parameter, 0),
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{
+					RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+				},
 				Tags:                make([]*cloudformation.Tag, 0),
 				TemplateBody:        aws.String(string(stack.Template)),
 				UsePreviousTemplate: aws.Bool(false),
@@ -0,0 +1,41 @@
+package command
+
+import (
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+// Status reports where each stack stands in a single summary, making no
+// changes.
+func Status(
+	ctx context.Context,
+	newClient func(*config.Stack) *stratus.Client,
+	stacks config.Stacks,
+) error {
+	logger := context.Logger(ctx)
+
+	logger.Title("Describe stack status")
+
+	summaries := make(stratus.StackSummaries, len(stacks))
+
+	for index, stack := range stacks {
+		// the checksum covers packaged assets, so it can be compared against the
+		// last executed change set
+		err := stack.Package()
+		if err != nil {
+			return err
+		}
+
+		summary, err := newClient(stack).DescribeSummary(ctx, stack)
+		if err != nil {
+			return err
+		}
+
+		summaries[index] = summary
+	}
+
+	logger.Data(summaries)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+// Validate reports on a stack that has passed config validation. The checks
+// themselves run as the config is loaded, with AWS placeholders left
+// unresolved, so no AWS call is made.
+func Validate(
+	ctx context.Context,
+	_ *stratus.Client,
+	stack *config.Stack,
+) error {
+	logger := context.Logger(ctx)
+
+	logger.Title("Validate config")
+
+	logger.Data(fmt.Sprintf("Stack '%s' is valid.", stack.Name))
+
+	return nil
+}
@@ -108,6 +108,23 @@ func Test_CalculateChecksum(t *testing.T) {
 			}),
 			expected: "e5fcf031009c4780d9993fa6afcee53dd1079a513b9033176ffa8f61f148fc4d",
 		},
+		{
+			description: "minimal config.Stack with role ARN",
+			input: stackInput(&config.Stack{
+				Name:                  "a",
+				Capabilities:          make([]string, 0),
+				Parameters:            make(config.StackParameters, 0),
+				RoleARN:               "arn:aws:iam::000000000000:role/cfn",
+				Tags:                  make(config.StackTags, 0),
+				TerminationProtection: false,
+
+				Policy:   []byte("e"),
+				Template: []byte("f"),
+
+				ArtefactBucket: "",
+			}),
+			expected: "e5e43f29d91477d1a8713f99c1dec4aecdd2db860c874bd6de1ba0e20c940002",
+		},
 		{
 			description: "nil config.Stack",
 			input:       stackInput(new(config.Stack)),
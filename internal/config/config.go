@@ -1,11 +1,19 @@
 package config
 
 import (
-	"io/ioutil"
-	"path/filepath"
-	"strings"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awsutil"
+
+	"github.com/72636c/stratus/internal/log"
+)
+
+const (
+	// CapabilitiesAuto acknowledges whatever capabilities ValidateTemplate
+	// reports that a template requires.
+	CapabilitiesAuto = "auto"
 )
 
 type Config struct {
@@ -13,21 +21,32 @@ type Config struct {
 }
 
 func FromPath(path string) (*Config, error) {
-	extension := strings.ToLower(filepath.Ext(path))
+	return FromPathWithEnvironment(path, "")
+}
+
+// FromPathWithEnvironment loads a config with the named environment overlay
+// patched over its defaults and stacks. An empty name applies no overlay.
+func FromPathWithEnvironment(path string, environment string) (*Config, error) {
+	d := &decoder{
+		environment: environment,
+	}
 
-	data, err := ioutil.ReadFile(path)
+	raw, err := d.decodeFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	var raw *RawConfig
-
-	err = Unmarshal(extension, data, &raw)
+	err = raw.Environments.checkEnvironment(environment)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: validate config
+	raw.inheritDefaults()
+
+	err = Validate(raw, path)
+	if err != nil {
+		return nil, err
+	}
 
 	return fromRawConfig(raw, path)
 }
@@ -47,20 +66,43 @@ func (stacks Stacks) Find(stackName string) (*Stack, bool) {
 type Stack struct {
 	Name string
 
+	DependsOn []string `json:",omitempty"`
+
+	AcknowledgeDestructive []string           `json:",omitempty"`
+	Protect                []*StackProtection `json:",omitempty"`
+
+	AutoCapabilities      bool `json:",omitempty"`
 	Capabilities          []string
+	DisableRollback       bool     `json:",omitempty"`
+	NotificationARNs      []string `json:",omitempty"`
 	Parameters            StackParameters
 	Region                *string
+	RoleARN               string                 `json:",omitempty"`
+	RollbackTriggers      *StackRollbackTriggers `json:",omitempty"`
 	Tags                  StackTags
 	TerminationProtection bool
 
+	PollInterval time.Duration `json:"-"`
+	Timeout      time.Duration `json:"-"`
+
 	Policy   []byte `json:"-"`
 	Template []byte `json:"-"`
 
-	ArtefactBucket string `json:",omitempty"`
-	PolicyKey      string `json:",omitempty"`
-	TemplateKey    string `json:",omitempty"`
+	ArtefactBucket string        `json:",omitempty"`
+	Assets         []*StackAsset `json:"-"`
+	PolicyKey      string        `json:",omitempty"`
+	TemplateKey    string        `json:",omitempty"`
+
+	AssumeRoleARN string `json:",omitempty"`
+	ExternalID    string `json:"-"`
+	SessionName   string `json:",omitempty"`
 
 	Checksum string
+
+	packaged          bool
+	policyExtension   string
+	templateDir       string
+	templateExtension string
 }
 
 func (stack *Stack) Hashable() interface{} {
@@ -71,29 +113,145 @@ func (stack *Stack) Hashable() interface{} {
 	return struct {
 		Name string
 
+		DependsOn []string `json:"-"`
+
+		AcknowledgeDestructive []string           `json:"-"`
+		Protect                []*StackProtection `json:"-"`
+
+		AutoCapabilities      bool `json:",omitempty"`
 		Capabilities          []string
+		DisableRollback       bool     `json:",omitempty"`
+		NotificationARNs      []string `json:",omitempty"`
 		Parameters            StackParameters
-		Region                *string `json:"-"`
+		Region                *string                `json:"-"`
+		RoleARN               string                 `json:",omitempty"`
+		RollbackTriggers      *StackRollbackTriggers `json:",omitempty"`
 		Tags                  StackTags
 		TerminationProtection bool
 
+		PollInterval time.Duration `json:"-"`
+		Timeout      time.Duration `json:"-"`
+
 		Policy   []byte
 		Template []byte
 
 		ArtefactBucket string
-		PolicyKey      string `json:"-"`
-		TemplateKey    string `json:"-"`
+		Assets         []*StackAsset `json:"-"`
+		PolicyKey      string        `json:"-"`
+		TemplateKey    string        `json:"-"`
+
+		AssumeRoleARN string `json:"-"`
+		ExternalID    string `json:"-"`
+		SessionName   string `json:"-"`
 
 		Checksum string `json:"-"`
+
+		packaged          bool
+		policyExtension   string
+		templateDir       string
+		templateExtension string
 	}(*stack)
 }
 
+func (stack *Stack) LogType() string {
+	return "stack"
+}
+
+// Scope identifies the account and region that the stack is deployed to.
+func (stack *Stack) Scope() Scope {
+	scope := Scope{
+		AssumeRoleARN: stack.AssumeRoleARN,
+		ExternalID:    stack.ExternalID,
+		SessionName:   stack.SessionName,
+	}
+
+	if stack.Region != nil {
+		scope.Region = *stack.Region
+	}
+
+	return scope
+}
+
+// Package rewrites local paths in the template to artefact bucket keys and
+// collects the assets to upload. It is left until the template is staged or
+// compared against a change set, so other commands don't zip and hash assets.
+// A stack that wasn't loaded from a file has no local paths to resolve.
+func (stack *Stack) Package() error {
+	if stack.packaged || stack.templateDir == "" {
+		return nil
+	}
+
+	template, assets, err := packageTemplate(stack.ArtefactBucket, stack.templateDir, stack.Template)
+	if err != nil {
+		return fmt.Errorf("stack '%s' template: %s", stack.Name, err)
+	}
+
+	stack.Template = template
+	stack.Assets = assets
+	stack.packaged = true
+
+	return stack.setChecksum()
+}
+
 func (stack *Stack) ShouldUpload() bool {
 	return stack.ArtefactBucket != ""
 }
 
+func (stack *Stack) setChecksum() error {
+	checksum, err := CalculateChecksum(stack.Hashable())
+	if err != nil {
+		return err
+	}
+
+	stack.Checksum = checksum
+
+	if stack.ArtefactBucket != "" {
+		keyFormat := fmt.Sprintf("stratus/%s/%s/%%s%%s", stack.Name, stack.Checksum)
+
+		stack.PolicyKey = fmt.Sprintf(keyFormat, "policy", stack.policyExtension)
+		stack.TemplateKey = fmt.Sprintf(keyFormat, "template", stack.templateExtension)
+	}
+
+	return nil
+}
+
+// MarshalJSON redacts secret values, as stacks are logged as JSON and YAML.
+func (stack *Stack) MarshalJSON() ([]byte, error) {
+	type stackAlias Stack
+
+	return json.Marshal((*stackAlias)(stack.redacted()))
+}
+
 func (stack *Stack) String() string {
-	return awsutil.Prettify(stack)
+	return awsutil.Prettify(stack.redacted())
+}
+
+// redacted returns a copy of the stack with secret values masked.
+func (stack *Stack) redacted() *Stack {
+	if stack == nil {
+		return nil
+	}
+
+	redacted := *stack
+
+	redacted.Parameters = make(StackParameters, len(stack.Parameters))
+	for index, parameter := range stack.Parameters {
+		redacted.Parameters[index] = &StackParameter{
+			Key:       parameter.Key,
+			Sensitive: parameter.Sensitive,
+			Value:     log.RedactParameterValue(parameter.Value),
+		}
+	}
+
+	redacted.Tags = make(StackTags, len(stack.Tags))
+	for index, tag := range stack.Tags {
+		redacted.Tags[index] = &StackTag{
+			Key:   tag.Key,
+			Value: log.Redact(tag.Value),
+		}
+	}
+
+	return &redacted
 }
 
 type StackParameters []*StackParameter
@@ -108,9 +266,35 @@ func (parameters StackParameters) Contains(key, value string) bool {
 	return false
 }
 
+// MarkSensitive flags the parameters with the given keys as sensitive, so
+// that their values are masked in log output.
+func (parameters StackParameters) MarkSensitive(keys ...string) {
+	for _, parameter := range parameters {
+		if containsString(keys, parameter.Key) {
+			parameter.Sensitive = true
+		}
+	}
+
+	parameters.registerSecrets()
+}
+
+func (parameters StackParameters) registerSecrets() {
+	for _, parameter := range parameters {
+		if parameter.Sensitive {
+			log.RegisterSecret(parameter.Value)
+		}
+	}
+}
+
 type StackParameter struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Key       string `json:"key"`
+	Sensitive bool   `json:"-"`
+	Value     string `json:"value"`
+}
+
+type StackRollbackTriggers struct {
+	AlarmARNs         []string `json:"alarmArns"`
+	MonitoringMinutes int64    `json:"monitoringMinutes"`
 }
 
 type StackTags []*StackTag
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// decoder carries the state of decoding a config file and the files that it
+// includes. Each file is decoded with its own copy, so the state of an
+// including file is never modified by the files that it includes.
+type decoder struct {
+	// environment names the overlay to apply, if any
+	environment string
+
+	// paths holds the chain of files being decoded, innermost last, so that
+	// includes resolve relative to the including file
+	paths []string
+
+	// scope holds the defaults-level role, so stacks without their own role
+	// resolve placeholders in the right account
+	scope Scope
+
+	// stackScopes holds the selected environment's stack-level roles by stack
+	// name, as they override the stack's own
+	stackScopes map[string]Scope
+
+	// awsMapper resolves AWS placeholders in the scope of the stack being
+	// decoded, if it has one; see decodeStack
+	awsMapper Mapper
+}
+
+// decodableConfig ties a config to the decoder of the file it is read from.
+type decodableConfig struct {
+	decoder *decoder
+	raw     *RawConfig
+}
+
+// mapper finds the mapper for a placeholder prefix, preferring the decoder's
+// scoped AWS mapper to the one registered by Init.
+func (d *decoder) mapper(prefix string) (Mapper, bool) {
+	if prefix == "aws" && d.awsMapper != nil {
+		return d.awsMapper, true
+	}
+
+	return mapperStore.Get(prefix)
+}
+
+func (d *decoder) path() string {
+	if len(d.paths) == 0 {
+		return ""
+	}
+
+	return d.paths[len(d.paths)-1]
+}
+
+func (d *decoder) decodeFile(path string) (*RawConfig, error) {
+	err := d.checkIncludeCycle(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := *d
+	file.paths = append(d.paths[:len(d.paths):len(d.paths)], path)
+
+	raw := new(RawConfig)
+
+	err = Unmarshal(
+		strings.ToLower(filepath.Ext(path)),
+		data,
+		&decodableConfig{decoder: &file, raw: raw},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+func (c *decodableConfig) UnmarshalJSON(data []byte) error {
+	doc, err := newJSONDocument(data)
+	if err != nil {
+		return err
+	}
+
+	return c.decoder.decodeConfig(c.raw, doc)
+}
+
+func (c *decodableConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc, err := newYAMLDocument(unmarshal)
+	if err != nil {
+		return err
+	}
+
+	return c.decoder.decodeConfig(c.raw, doc)
+}
+
+// UnmarshalJSON decodes a standalone config, which applies no environment
+// overlay and resolves includes relative to the working directory.
+func (raw *RawConfig) UnmarshalJSON(data []byte) error {
+	return (&decodableConfig{decoder: new(decoder), raw: raw}).UnmarshalJSON(data)
+}
+
+// UnmarshalYAML decodes a standalone config, which applies no environment
+// overlay and resolves includes relative to the working directory.
+func (raw *RawConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return (&decodableConfig{decoder: new(decoder), raw: raw}).UnmarshalYAML(unmarshal)
+}
+
+// decodeConfig resolves the defaults-level and environment-level roles ahead of
+// the rest of the config, and decodes the stacks in them. The selected
+// environment overlay is applied once included files are merged, so that it
+// can patch their stacks too. Included files inherit the roles of the
+// including file.
+//
+// Each placeholder is resolved once, in the document, so that decoding part of
+// the config ahead of the rest doesn't repeat AWS calls.
+func (d *decoder) decodeConfig(raw *RawConfig, doc *document) error {
+	type rawConfigAlias RawConfig
+
+	err := doc.resolve(d.mapper, "defaults", "include")
+	if err != nil {
+		return err
+	}
+
+	var prefix struct {
+		Defaults     rawScope        `json:"defaults"`
+		Environments RawEnvironments `json:"environments"`
+	}
+
+	err = doc.unmarshal(&prefix)
+	if err != nil {
+		return err
+	}
+
+	environment := prefix.Environments.selected(d.environment)
+
+	err = d.resolveEnvironmentScopes(environment)
+	if err != nil {
+		return err
+	}
+
+	var overlay struct {
+		Defaults rawScope         `json:"defaults"`
+		Stacks   []*namedRawScope `json:"stacks"`
+	}
+
+	err = environment.unmarshal(&overlay)
+	if err != nil {
+		return err
+	}
+
+	file := *d
+
+	file.scope = d.scope.
+		withOverlay(prefix.Defaults.Scope()).
+		withOverlay(overlay.Defaults.Scope())
+
+	file.stackScopes = make(map[string]Scope, len(overlay.Stacks)+len(d.stackScopes))
+
+	for _, stackScope := range overlay.Stacks {
+		if stackScope != nil {
+			file.stackScopes[stackScope.Name.String()] = stackScope.Scope()
+		}
+	}
+
+	// the including file's overlay is applied last, so its roles win
+	for name, stackScope := range d.stackScopes {
+		file.stackScopes[name] = file.stackScopes[name].withOverlay(stackScope)
+	}
+
+	err = doc.unmarshal((*rawConfigAlias)(raw))
+	if err != nil {
+		return err
+	}
+
+	err = file.decodeStacks(raw.Stacks)
+	if err != nil {
+		return err
+	}
+
+	raw.setOrigin(file.path())
+
+	err = file.include(raw)
+	if err != nil {
+		return err
+	}
+
+	return file.applyEnvironment(raw, environment)
+}
+
+func (d *decoder) decodeStacks(rawStacks []*RawStack) error {
+	for _, rawStack := range rawStacks {
+		err := d.decodeStack(rawStack)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
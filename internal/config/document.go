@@ -0,0 +1,190 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// resolvedPrefix marks a string that the decoder has already resolved, so that
+// it is neither resolved again by the decoder nor by String, Bool and Int. A
+// resolved value may well contain braces of its own.
+const resolvedPrefix = "\x00resolved\x00"
+
+// document holds a config file or part of one as a generic tree, so that the
+// decoder can resolve its placeholders with the right mappers before decoding
+// it into the raw config types.
+type document struct {
+	tree interface{}
+
+	// decode re-encodes the tree in its source format and decodes it into model
+	decode func(tree interface{}, model interface{}) error
+}
+
+func newJSONDocument(data []byte) (*document, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var tree interface{}
+
+	err := decoder.Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{tree: tree, decode: decodeJSONTree}, nil
+}
+
+func newYAMLDocument(unmarshal func(interface{}) error) (*document, error) {
+	var tree interface{}
+
+	err := unmarshal(&tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{tree: normaliseYAMLTree(tree), decode: decodeYAMLTree}, nil
+}
+
+func decodeJSONTree(tree interface{}, model interface{}) error {
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, model)
+}
+
+func decodeYAMLTree(tree interface{}, model interface{}) error {
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(data, model)
+}
+
+// normaliseYAMLTree converts YAML mappings to string-keyed maps, like their
+// JSON counterparts.
+func normaliseYAMLTree(node interface{}) interface{} {
+	switch typed := node.(type) {
+	case map[interface{}]interface{}:
+		fields := make(map[string]interface{}, len(typed))
+
+		for key, value := range typed {
+			fields[fmt.Sprint(key)] = normaliseYAMLTree(value)
+		}
+
+		return fields
+
+	case []interface{}:
+		for index, item := range typed {
+			typed[index] = normaliseYAMLTree(item)
+		}
+
+		return typed
+
+	default:
+		return node
+	}
+}
+
+func (doc *document) unmarshal(model interface{}) error {
+	return doc.decode(doc.tree, model)
+}
+
+// resolve resolves the placeholders in the named top-level fields, or in the
+// whole document if none are named.
+func (doc *document) resolve(lookup mapperLookup, keys ...string) error {
+	if len(keys) == 0 {
+		var err error
+
+		doc.tree, err = resolveTree(doc.tree, lookup)
+
+		return err
+	}
+
+	return resolveFields(doc.tree, lookup, keys...)
+}
+
+// resolveFields resolves the placeholders in the named fields of a mapping.
+func resolveFields(node interface{}, lookup mapperLookup, keys ...string) error {
+	fields, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, key := range keys {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		resolved, err := resolveTree(value, lookup)
+		if err != nil {
+			return err
+		}
+
+		fields[key] = resolved
+	}
+
+	return nil
+}
+
+// resolveTree resolves each string in the tree at most once, marking it as
+// resolved.
+func resolveTree(node interface{}, lookup mapperLookup) (interface{}, error) {
+	switch typed := node.(type) {
+	case string:
+		if strings.HasPrefix(typed, resolvedPrefix) {
+			return typed, nil
+		}
+
+		resolved, err := resolve(typed, lookup)
+		if err != nil {
+			return nil, err
+		}
+
+		return resolvedPrefix + resolved, nil
+
+	case map[string]interface{}:
+		for key, value := range typed {
+			resolved, err := resolveTree(value, lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[key] = resolved
+		}
+
+		return typed, nil
+
+	case []interface{}:
+		for index, item := range typed {
+			resolved, err := resolveTree(item, lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			typed[index] = resolved
+		}
+
+		return typed, nil
+
+	default:
+		return node, nil
+	}
+}
+
+// field returns the named field of a mapping.
+func (doc *document) field(key string) interface{} {
+	fields, ok := doc.tree.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	return fields[key]
+}
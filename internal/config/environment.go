@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnmarshalJSON defers decoding until the environment is selected, so that
+// placeholders in other environments are never resolved.
+func (raw *RawEnvironment) UnmarshalJSON(data []byte) (err error) {
+	raw.document, err = newJSONDocument(data)
+
+	return
+}
+
+// UnmarshalYAML defers decoding until the environment is selected, so that
+// placeholders in other environments are never resolved.
+func (raw *RawEnvironment) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	raw.document, err = newYAMLDocument(unmarshal)
+
+	return
+}
+
+func (raw *RawEnvironment) unmarshal(model interface{}) error {
+	if raw == nil || raw.document == nil {
+		return nil
+	}
+
+	return raw.document.unmarshal(model)
+}
+
+// resolveEnvironmentScopes resolves the selected environment's defaults and the
+// roles of its stacks in the including scope, ahead of the roles being decoded.
+// The rest of each stack is resolved in its own scope; see decodeStack.
+func (d *decoder) resolveEnvironmentScopes(raw *RawEnvironment) error {
+	if raw == nil || raw.document == nil {
+		return nil
+	}
+
+	err := raw.document.resolve(d.mapper, "defaults")
+	if err != nil {
+		return err
+	}
+
+	stacks, _ := raw.document.field("stacks").([]interface{})
+
+	for _, stack := range stacks {
+		err = resolveFields(stack, d.mapper, rawScopeKeys...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selected returns the named environment, or nil if this file doesn't declare
+// it. An included file can leave out environments that don't concern its
+// stacks; see checkEnvironment.
+func (environments RawEnvironments) selected(name string) *RawEnvironment {
+	if name == "" {
+		return nil
+	}
+
+	environment, ok := environments[name]
+	if !ok {
+		return nil
+	}
+
+	if environment == nil {
+		environment = new(RawEnvironment)
+	}
+
+	return environment
+}
+
+// checkEnvironment fails on a name that no file in the config declares, which
+// is most likely a typo.
+func (environments RawEnvironments) checkEnvironment(name string) error {
+	if _, ok := environments[name]; ok || name == "" {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"environment '%s' not found in config; expected one of [%s]",
+		name,
+		strings.Join(environments.names(), ", "),
+	)
+}
+
+func (environments RawEnvironments) names() []string {
+	names := make([]string, 0, len(environments))
+
+	for name := range environments {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// applyEnvironment decodes the selected environment and patches it over the
+// defaults and the stacks that it names, including those of included files.
+func (d *decoder) applyEnvironment(raw *RawConfig, environment *RawEnvironment) error {
+	if environment == nil {
+		return nil
+	}
+
+	type rawEnvironmentAlias RawEnvironment
+
+	err := environment.unmarshal((*rawEnvironmentAlias)(environment))
+	if err != nil {
+		return err
+	}
+
+	err = d.decodeStacks(environment.Stacks)
+	if err != nil {
+		return err
+	}
+
+	raw.Defaults.patch(&environment.Defaults)
+
+	for _, overlay := range environment.Stacks {
+		if overlay == nil {
+			continue
+		}
+
+		rawStack, ok := raw.findStack(overlay.Name.String())
+		if !ok {
+			return fmt.Errorf(
+				"environment '%s' stack '%s' not found in config",
+				d.environment,
+				overlay.Name.String(),
+			)
+		}
+
+		rawStack.patch(overlay)
+	}
+
+	return nil
+}
+
+func (raw *RawConfig) findStack(name string) (*RawStack, bool) {
+	for _, rawStack := range raw.Stacks {
+		if rawStack != nil && rawStack.Name.String() == name {
+			return rawStack, true
+		}
+	}
+
+	return nil, false
+}
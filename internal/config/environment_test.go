@@ -0,0 +1,274 @@
+package config_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+const environmentConfig = `
+defaults:
+  assumeRoleArn: arn:aws:iam::000000000000:role/deployer
+  capabilities: [CAPABILITY_IAM]
+  parameters:
+    - key: Environment
+      value: dev
+    - key: LogLevel
+      value: debug
+  policyFile: policy.json
+  region: ap-southeast-2
+  tags:
+    - key: team
+      value: platform
+  templateFile: template.yaml
+  terminationProtection: true
+
+environments:
+  prod:
+    defaults:
+      assumeRoleArn: arn:aws:iam::111111111111:role/deployer
+      parameters:
+        - key: Environment
+          value: prod
+    stacks:
+      - name: b
+        assumeRoleArn: arn:aws:iam::222222222222:role/deployer
+        parameters:
+          - key: LogLevel
+            value: warn
+        terminationProtection: true
+  test:
+    stacks:
+      - name: a
+        parameters:
+          - key: Unresolved
+            value: '{{env:STRATUS_TEST_UNSET_VARIABLE}}'
+
+stacks:
+  - name: a
+    capabilities: []
+    parameters:
+      - key: Size
+        value: small
+
+  - name: b
+    assumeRoleArn: arn:aws:iam::333333333333:role/deployer
+    parameters:
+      - key: LogLevel
+        value: info
+    region: us-east-1
+    tags:
+      - key: team
+        value: app
+      - key: tier
+        value: web
+    terminationProtection: false
+`
+
+func Test_FromPathWithEnvironment(t *testing.T) {
+	files := map[string]string{
+		"stratus.yaml":  environmentConfig,
+		"policy.json":   "{}",
+		"template.yaml": "Resources: {}",
+	}
+
+	dir := writeFiles(t, files)
+
+	type expectedStack struct {
+		assumeRoleARN         string
+		capabilities          []string
+		parameters            map[string]string
+		region                string
+		tags                  map[string]string
+		terminationProtection bool
+	}
+
+	testCases := []struct {
+		description   string
+		environment   string
+		expected      map[string]expectedStack
+		expectedError string
+	}{
+		{
+			description: "defaults only",
+			environment: "",
+			expected: map[string]expectedStack{
+				"a": {
+					assumeRoleARN: "arn:aws:iam::000000000000:role/deployer",
+					capabilities:  []string{},
+					parameters: map[string]string{
+						"Environment": "dev",
+						"LogLevel":    "debug",
+						"Size":        "small",
+					},
+					region:                "ap-southeast-2",
+					tags:                  map[string]string{"team": "platform"},
+					terminationProtection: true,
+				},
+				"b": {
+					assumeRoleARN: "arn:aws:iam::333333333333:role/deployer",
+					capabilities:  []string{"CAPABILITY_IAM"},
+					parameters: map[string]string{
+						"Environment": "dev",
+						"LogLevel":    "info",
+					},
+					region:                "us-east-1",
+					tags:                  map[string]string{"team": "app", "tier": "web"},
+					terminationProtection: false,
+				},
+			},
+		},
+		{
+			description: "prod overlay",
+			environment: "prod",
+			expected: map[string]expectedStack{
+				"a": {
+					assumeRoleARN: "arn:aws:iam::111111111111:role/deployer",
+					capabilities:  []string{},
+					parameters: map[string]string{
+						"Environment": "prod",
+						"LogLevel":    "debug",
+						"Size":        "small",
+					},
+					region:                "ap-southeast-2",
+					tags:                  map[string]string{"team": "platform"},
+					terminationProtection: true,
+				},
+				"b": {
+					assumeRoleARN: "arn:aws:iam::222222222222:role/deployer",
+					capabilities:  []string{"CAPABILITY_IAM"},
+					parameters: map[string]string{
+						"Environment": "prod",
+						"LogLevel":    "warn",
+					},
+					region:                "us-east-1",
+					tags:                  map[string]string{"team": "app", "tier": "web"},
+					terminationProtection: true,
+				},
+			},
+		},
+		{
+			description:   "unknown environment",
+			environment:   "staging",
+			expectedError: "environment 'staging' not found in config; expected one of [prod, test]",
+		},
+		{
+			description:   "selected environment placeholders",
+			environment:   "test",
+			expectedError: "environment variable 'STRATUS_TEST_UNSET_VARIABLE' not set",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			cfg, err := config.FromPathWithEnvironment(
+				filepath.Join(dir, "stratus.yaml"),
+				testCase.environment,
+			)
+
+			if testCase.expectedError != "" {
+				assert.EqualError(err, testCase.expectedError)
+				return
+			}
+
+			require.NoError(err)
+			require.Len(cfg.Stacks, len(testCase.expected))
+
+			for name, expected := range testCase.expected {
+				stack, ok := cfg.Stacks.Find(name)
+				require.True(ok, name)
+
+				parameters := make(map[string]string)
+				for _, parameter := range stack.Parameters {
+					parameters[parameter.Key] = parameter.Value
+				}
+
+				tags := make(map[string]string)
+				for _, tag := range stack.Tags {
+					tags[tag.Key] = tag.Value
+				}
+
+				assert.Equal(expected.assumeRoleARN, stack.AssumeRoleARN, name)
+				assert.Equal(expected.capabilities, stack.Capabilities, name)
+				assert.Equal(expected.parameters, parameters, name)
+				require.NotNil(stack.Region, name)
+				assert.Equal(expected.region, *stack.Region, name)
+				assert.Equal(expected.tags, tags, name)
+				assert.Equal(expected.terminationProtection, stack.TerminationProtection, name)
+			}
+		})
+	}
+}
+
+func Test_FromPathWithEnvironment_UnknownStack(t *testing.T) {
+	files := map[string]string{
+		"stratus.json": `{
+  "environments": {
+    "prod": {
+      "stacks": [{"name": "missing", "region": "us-east-1"}]
+    }
+  },
+  "stacks": [
+    {"name": "a", "policyFile": "policy.json", "templateFile": "template.yaml"}
+  ]
+}`,
+		"policy.json":   "{}",
+		"template.yaml": "Resources: {}",
+	}
+
+	dir := writeFiles(t, files)
+
+	_, err := config.FromPathWithEnvironment(filepath.Join(dir, "stratus.json"), "prod")
+	assert.EqualError(t, err, "environment 'prod' stack 'missing' not found in config")
+
+	cfg, err := config.FromPath(filepath.Join(dir, "stratus.json"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Stacks[0].Region)
+}
+
+func Test_FromPathWithEnvironment_Concurrent(t *testing.T) {
+	files := map[string]string{
+		"stratus.yaml":  environmentConfig,
+		"policy.json":   "{}",
+		"template.yaml": "Resources: {}",
+	}
+
+	dir := writeFiles(t, files)
+
+	expected := map[string]string{
+		"":     "arn:aws:iam::333333333333:role/deployer",
+		"prod": "arn:aws:iam::222222222222:role/deployer",
+	}
+
+	var wg sync.WaitGroup
+
+	for index := 0; index < 8; index++ {
+		for environment, assumeRoleARN := range expected {
+			wg.Add(1)
+
+			go func(environment, assumeRoleARN string) {
+				defer wg.Done()
+
+				cfg, err := config.FromPathWithEnvironment(filepath.Join(dir, "stratus.yaml"), environment)
+				if !assert.NoError(t, err, environment) {
+					return
+				}
+
+				stack, ok := cfg.Stacks.Find("b")
+				if assert.True(t, ok, environment) {
+					assert.Equal(t, assumeRoleARN, stack.AssumeRoleARN, environment)
+				}
+			}(environment, assumeRoleARN)
+		}
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,10 @@
+package config
+
+// InitScopedAWSMapper registers newMapper in place of AWS clients, so that
+// tests can see which scope each AWS placeholder is resolved in.
+func InitScopedAWSMapper(newMapper func(Scope) Mapper) {
+	Init(nil)
+
+	mapperStore.Set("aws", newMapper(Scope{}))
+	newScopedAWSMapper = newMapper
+}
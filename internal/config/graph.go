@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dependencies lists the names of the stacks that must be deployed before this
+// one, combining explicit dependsOn entries with stack output placeholders.
+func (stack *Stack) Dependencies() []string {
+	seen := make(map[string]struct{})
+	slice := make([]string, 0)
+
+	add := func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+
+		seen[name] = struct{}{}
+		slice = append(slice, name)
+	}
+
+	for _, name := range stack.DependsOn {
+		add(name)
+	}
+
+	for _, reference := range stack.OutputReferences() {
+		add(reference.StackName)
+	}
+
+	return slice
+}
+
+// Reverse returns the stacks in reverse order, which tears down dependents
+// before their dependencies when applied to sorted stacks.
+func (stacks Stacks) Reverse() Stacks {
+	slice := make(Stacks, len(stacks))
+
+	for index, stack := range stacks {
+		slice[len(stacks)-1-index] = stack
+	}
+
+	return slice
+}
+
+// Sort orders the stacks so that each stack follows its dependencies. Stacks
+// without a dependency relationship keep their relative order in the config.
+func (stacks Stacks) Sort() (Stacks, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(stacks))
+	slice := make(Stacks, 0, len(stacks))
+
+	var visit func(stack *Stack, path []string) error
+
+	visit = func(stack *Stack, path []string) error {
+		path = append(path, stack.Name)
+
+		switch state[stack.Name] {
+		case visited:
+			return nil
+
+		case visiting:
+			return fmt.Errorf("stack dependency cycle '%s'", strings.Join(path, " -> "))
+		}
+
+		state[stack.Name] = visiting
+
+		for _, name := range stack.Dependencies() {
+			dependency, ok := stacks.Find(name)
+			if !ok {
+				return fmt.Errorf(
+					"stack '%s' depends on '%s', which is not found in config",
+					stack.Name,
+					name,
+				)
+			}
+
+			err := visit(dependency, path)
+			if err != nil {
+				return err
+			}
+		}
+
+		state[stack.Name] = visited
+		slice = append(slice, stack)
+
+		return nil
+	}
+
+	for _, stack := range stacks {
+		err := visit(stack, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return slice, nil
+}
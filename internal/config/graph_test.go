@@ -0,0 +1,177 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+func stackNames(stacks config.Stacks) []string {
+	slice := make([]string, len(stacks))
+
+	for index, stack := range stacks {
+		slice[index] = stack.Name
+	}
+
+	return slice
+}
+
+func Test_Stacks_Sort(t *testing.T) {
+	testCases := []struct {
+		description   string
+		input         config.Stacks
+		expected      []string
+		expectedError string
+	}{
+		{
+			description: "no dependencies",
+			input: config.Stacks{
+				{Name: "a"},
+				{Name: "b"},
+				{Name: "c"},
+			},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			description: "explicit dependencies",
+			input: config.Stacks{
+				{Name: "a", DependsOn: []string{"c"}},
+				{Name: "b"},
+				{Name: "c", DependsOn: []string{"b"}},
+			},
+			expected: []string{"b", "c", "a"},
+		},
+		{
+			description: "output placeholder dependencies",
+			input: config.Stacks{
+				{
+					Name: "a",
+					Parameters: config.StackParameters{
+						{
+							Key:   "Vpc",
+							Value: "${stack:b:output:VpcId}",
+						},
+					},
+				},
+				{Name: "b"},
+			},
+			expected: []string{"b", "a"},
+		},
+		{
+			description: "dependency cycle",
+			input: config.Stacks{
+				{Name: "a", DependsOn: []string{"b"}},
+				{Name: "b", DependsOn: []string{"a"}},
+			},
+			expectedError: "stack dependency cycle 'a -> b -> a'",
+		},
+		{
+			description: "unknown dependency",
+			input: config.Stacks{
+				{Name: "a", DependsOn: []string{"z"}},
+			},
+			expectedError: "stack 'a' depends on 'z', which is not found in config",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			actual, err := testCase.input.Sort()
+			if testCase.expectedError == "" {
+				assert.Equal(testCase.expected, stackNames(actual))
+				assert.NoError(err)
+			} else {
+				require.Error(err)
+				assert.Contains(err.Error(), testCase.expectedError)
+			}
+		})
+	}
+}
+
+func Test_Stacks_Reverse(t *testing.T) {
+	assert := assert.New(t)
+
+	stacks := config.Stacks{
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "c"},
+	}
+
+	assert.Equal([]string{"c", "b", "a"}, stackNames(stacks.Reverse()))
+	assert.Equal([]string{"a", "b", "c"}, stackNames(stacks))
+}
+
+func Test_Stack_ResolveOutputs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	vpcID, err := config.Resolve("{{stack:network:output:VpcId}}")
+	require.NoError(err)
+
+	subnetID, err := config.Resolve("{{stack:network:output:SubnetId}}")
+	require.NoError(err)
+
+	stack := &config.Stack{
+		Name: "a",
+		Parameters: config.StackParameters{
+			{
+				Key:   "Vpc",
+				Value: vpcID,
+			},
+		},
+		Tags: config.StackTags{
+			{
+				Key:   "Subnet",
+				Value: subnetID,
+			},
+		},
+	}
+
+	assert.Equal(
+		[]config.OutputReference{
+			{StackName: "network", OutputKey: "VpcId"},
+			{StackName: "network", OutputKey: "SubnetId"},
+		},
+		stack.OutputReferences(),
+	)
+
+	checksum := stack.Checksum
+
+	err = stack.ResolveOutputs(func(reference config.OutputReference) (string, error) {
+		return reference.StackName + "-" + reference.OutputKey, nil
+	})
+	require.NoError(err)
+
+	assert.Equal("network-VpcId", stack.Parameters[0].Value)
+	assert.Equal("network-SubnetId", stack.Tags[0].Value)
+	assert.NotEqual(checksum, stack.Checksum)
+	assert.Empty(stack.OutputReferences())
+
+	err = (&config.Stack{
+		Name: "b",
+		Parameters: config.StackParameters{
+			{
+				Key:   "Vpc",
+				Value: vpcID,
+			},
+		},
+	}).ResolveOutputs(func(config.OutputReference) (string, error) {
+		return "", errors.New("output not found")
+	})
+	require.Error(err)
+	assert.Contains(err.Error(), "stack 'b' parameter 'Vpc': output not found")
+}
+
+func Test_Resolve_StackPlaceholder(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := config.Resolve("{{stack:network:VpcId}}")
+	assert.EqualError(err, "malformed stack placeholder 'network:VpcId'")
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// rawOrigin records where a stack was defined, for validation messages.
+type rawOrigin struct {
+	path  string
+	index int
+}
+
+func (raw *RawConfig) setOrigin(path string) {
+	for index, rawStack := range raw.Stacks {
+		if rawStack != nil {
+			rawStack.origin = rawOrigin{path: path, index: index}
+		}
+	}
+}
+
+// include decodes the files matching each include pattern and appends their
+// stacks. Each included file's defaults apply to its own stacks first, so the
+// nearest file wins, and its stack paths are rewritten to be relative to the
+// including file.
+func (d *decoder) include(raw *RawConfig) error {
+	path := d.path()
+	dir := filepath.Dir(path)
+
+	seen := make(map[string]struct{})
+
+	for _, pattern := range raw.Include {
+		includePaths, err := filepath.Glob(filepath.Join(dir, pattern.String()))
+		if err != nil {
+			return fmt.Errorf("%s: include '%s': %s", path, pattern.String(), err)
+		}
+
+		if len(includePaths) == 0 {
+			return fmt.Errorf("%s: include '%s' matches no files", path, pattern.String())
+		}
+
+		for _, includePath := range includePaths {
+			if _, ok := seen[includePath]; ok {
+				continue
+			}
+
+			seen[includePath] = struct{}{}
+
+			included, err := d.decodeFile(includePath)
+			if err != nil {
+				return fmt.Errorf("%s: %s", includePath, err)
+			}
+
+			relativeDir, err := filepath.Rel(dir, filepath.Dir(includePath))
+			if err != nil {
+				return err
+			}
+
+			raw.merge(included, relativeDir)
+		}
+	}
+
+	return nil
+}
+
+func (d *decoder) checkIncludeCycle(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	for index, decoding := range d.paths {
+		absDecoding, err := filepath.Abs(decoding)
+		if err != nil {
+			return err
+		}
+
+		if absDecoding == absPath {
+			chain := append(d.paths[index:len(d.paths):len(d.paths)], path)
+
+			return fmt.Errorf("include cycle %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	return nil
+}
+
+func (raw *RawConfig) merge(included *RawConfig, relativeDir string) {
+	included.inheritDefaults()
+
+	for _, rawStack := range included.Stacks {
+		if rawStack == nil {
+			continue
+		}
+
+		rawStack.Protect = append(
+			append(RawStackProtections(nil), included.Defaults.Protect...),
+			rawStack.Protect...,
+		)
+
+		if rawStack.artefactBucket == "" {
+			rawStack.artefactBucket = included.Defaults.ArtefactBucket
+		}
+
+		rawStack.ParametersFile = joinPath(relativeDir, rawStack.ParametersFile)
+		rawStack.PolicyFile = joinPath(relativeDir, rawStack.PolicyFile)
+		rawStack.TemplateFile = joinPath(relativeDir, rawStack.TemplateFile)
+	}
+
+	raw.Stacks = append(raw.Stacks, included.Stacks...)
+
+	for name, environment := range included.Environments {
+		if raw.Environments == nil {
+			raw.Environments = make(RawEnvironments)
+		}
+
+		if _, ok := raw.Environments[name]; !ok {
+			raw.Environments[name] = environment
+		}
+	}
+}
+
+func joinPath(dir string, path String) String {
+	if path == "" || filepath.IsAbs(path.String()) {
+		return path
+	}
+
+	return String(filepath.Join(dir, path.String()))
+}
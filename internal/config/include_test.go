@@ -0,0 +1,248 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+func Test_FromPath_Include(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files := map[string]string{
+		"stratus.yaml": `
+defaults:
+  region: ap-southeast-2
+  tags:
+    - key: owner
+      value: platform
+
+include:
+  - services/*/stratus.*
+
+stacks:
+  - name: network
+    policyFile: policy.json
+    templateFile: network.yaml
+`,
+		"policy.json":        "{}",
+		"network.yaml":       "Resources: {}",
+		"shared/policy.json": "{}",
+		"services/api/stratus.yaml": `
+defaults:
+  tags:
+    - key: owner
+      value: api
+  policyFile: ../../shared/policy.json
+
+include:
+  - workers.json
+
+stacks:
+  - name: api
+    dependsOn: [network]
+    region: us-east-1
+    templateFile: template.yaml
+`,
+		"services/api/template.yaml": "Resources: {}",
+		"services/api/workers.json": `{
+  "stacks": [
+    {"name": "api-workers", "templateFile": "workers/template.yaml"}
+  ]
+}`,
+		"services/api/workers/template.yaml": "Resources: {}",
+		"services/web/stratus.json": `{
+  "stacks": [
+    {"name": "web", "policyFile": "policy.json", "templateFile": "template.yaml"}
+  ]
+}`,
+		"services/web/policy.json":   "{}",
+		"services/web/template.yaml": "Resources: {}",
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+	require.NoError(err)
+
+	expected := map[string]struct {
+		owner  string
+		region string
+	}{
+		"network":     {owner: "platform", region: "ap-southeast-2"},
+		"api":         {owner: "api", region: "us-east-1"},
+		"api-workers": {owner: "api", region: "ap-southeast-2"},
+		"web":         {owner: "platform", region: "ap-southeast-2"},
+	}
+
+	require.Len(cfg.Stacks, len(expected))
+
+	for name, expected := range expected {
+		stack, ok := cfg.Stacks.Find(name)
+		require.True(ok, name)
+
+		require.Len(stack.Tags, 1, name)
+		assert.Equal(expected.owner, stack.Tags[0].Value, name)
+		require.NotNil(stack.Region, name)
+		assert.Equal(expected.region, *stack.Region, name)
+		assert.Equal("Resources: {}", string(stack.Template), name)
+	}
+}
+
+func Test_FromPath_Include_Errors(t *testing.T) {
+	testCases := []struct {
+		description   string
+		files         map[string]string
+		expectedError string
+	}{
+		{
+			description: "duplicate name",
+			files: map[string]string{
+				"stratus.yaml": `
+include: [other.yaml]
+stacks:
+  - name: a
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"other.yaml": `
+stacks:
+  - name: b
+    policyFile: policy.json
+    templateFile: template.yaml
+  - name: a
+    policyFile: policy.json
+    templateFile: missing.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			},
+			expectedError: "other.yaml: stacks[1] 'a': name: duplicates stacks[0] in ",
+		},
+		{
+			description: "missing file cites origin",
+			files: map[string]string{
+				"stratus.yaml": `
+include: [nested/other.yaml]
+stacks: []
+`,
+				"nested/other.yaml": `
+stacks:
+  - name: b
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+			},
+			expectedError: "other.yaml: stacks[0] 'b': policyFile: '" +
+				filepath.Join("nested", "policy.json") + "' does not exist",
+		},
+		{
+			description: "no matches",
+			files: map[string]string{
+				"stratus.yaml": `
+include: [services/*.yaml]
+stacks: []
+`,
+			},
+			expectedError: "stratus.yaml: include 'services/*.yaml' matches no files",
+		},
+		{
+			description: "cycle",
+			files: map[string]string{
+				"stratus.yaml": `
+include: [a.yaml]
+stacks: []
+`,
+				"a.yaml": `
+include: [b.yaml]
+`,
+				"b.yaml": `
+include: [a.yaml]
+`,
+			},
+			expectedError: "include cycle ",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			dir := writeFiles(t, testCase.files)
+
+			_, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+
+			if assert.Error(err) {
+				assert.Contains(err.Error(), testCase.expectedError)
+			}
+		})
+	}
+}
+
+func Test_FromPathWithEnvironment_Include(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files := map[string]string{
+		"stratus.yaml": `
+environments:
+  prod:
+    stacks:
+      - name: api
+        assumeRoleArn: arn:aws:iam::111111111111:role/deployer
+        parameters:
+          - key: Size
+            value: large
+
+include: [services/api.yaml]
+
+stacks: []
+`,
+		"services/api.yaml": `
+environments:
+  prod:
+    stacks:
+      - name: api
+        assumeRoleArn: arn:aws:iam::222222222222:role/deployer
+        parameters:
+          - key: LogLevel
+            value: warn
+          - key: Size
+            value: medium
+
+stacks:
+  - name: api
+    parameters:
+      - key: LogLevel
+        value: debug
+      - key: Size
+        value: small
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+		"services/policy.json":   "{}",
+		"services/template.yaml": "Resources: {}",
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPathWithEnvironment(filepath.Join(dir, "stratus.yaml"), "prod")
+	require.NoError(err)
+	require.Len(cfg.Stacks, 1)
+
+	stack := cfg.Stacks[0]
+
+	parameters := make(map[string]string)
+	for _, parameter := range stack.Parameters {
+		parameters[parameter.Key] = parameter.Value
+	}
+
+	// the including file's overlay is applied after the included file's own
+	assert.Equal("arn:aws:iam::111111111111:role/deployer", stack.AssumeRoleARN)
+	assert.Equal(map[string]string{"LogLevel": "warn", "Size": "large"}, parameters)
+}
@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
 )
 
 func fromRawConfig(rawConfig *RawConfig, path string) (*Config, error) {
@@ -12,6 +17,11 @@ func fromRawConfig(rawConfig *RawConfig, path string) (*Config, error) {
 		return nil, err
 	}
 
+	stacks, err = stacks.Sort()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &Config{
 		Stacks: stacks,
 	}
@@ -59,42 +69,115 @@ func fromRawStack(
 		return nil, err
 	}
 
+	fileParameters, err := readParametersFile(path, rawStack)
+	if err != nil {
+		return nil, fmt.Errorf("stack '%s' parameters file: %s", rawStack.Name.String(), err)
+	}
+
+	artefactBucket := rawStack.artefactBucket
+	if artefactBucket == "" {
+		artefactBucket = rawConfig.Defaults.ArtefactBucket
+	}
+
 	stack := &Stack{
 		Name: rawStack.Name.String(),
 
+		DependsOn: fromRawStackDependencies(rawStack.DependsOn),
+
+		AcknowledgeDestructive: fromRawStackAcknowledgements(rawStack.AcknowledgeDestructive),
+		Protect:                fromRawStackProtections(rawConfig.Defaults.Protect, rawStack.Protect),
+
+		AutoCapabilities:      rawStack.Capabilities.isAuto(),
 		Capabilities:          fromRawStackCapabilities(rawStack.Capabilities),
-		Parameters:            fromRawStackParameters(rawStack.Parameters),
+		DisableRollback:       rawStack.DisableRollback.Bool(),
+		NotificationARNs:      fromRawStackNotificationARNs(rawStack.NotificationARNs),
+		Parameters:            fromRawStackParameters(patchParameters(fileParameters, rawStack.Parameters)),
 		Region:                rawStack.Region.StringPointer(),
+		RoleARN:               fromRawStackRoleARN(rawConfig, rawStack),
+		RollbackTriggers:      fromRawStackRollbackTriggers(rawStack.RollbackTriggers),
 		Tags:                  fromRawStackTags(rawStack.Tags),
 		TerminationProtection: rawStack.TerminationProtection.Bool(),
 
+		PollInterval: fromRawDuration(rawStack.PollInterval, rawConfig.Defaults.PollInterval),
+		Timeout:      fromRawDuration(rawStack.Timeout, rawConfig.Defaults.Timeout),
+
 		Policy:   policy,
 		Template: template,
 
-		ArtefactBucket: rawConfig.Defaults.ArtefactBucket.String(),
+		ArtefactBucket: artefactBucket.String(),
+
+		AssumeRoleARN: rawStack.AssumeRoleARN.String(),
+		ExternalID:    rawStack.ExternalID.String(),
+		SessionName:   rawStack.SessionName.String(),
+
+		policyExtension:   filepath.Ext(rawStack.PolicyFile.String()),
+		templateDir:       filepath.Dir(templatePath),
+		templateExtension: filepath.Ext(rawStack.TemplateFile.String()),
+	}
+
+	noEchoKeys, err := toNoEchoParameterKeys(template)
+	if err != nil {
+		return nil, fmt.Errorf("stack '%s' template: %s", stack.Name, err)
+	}
+
+	stack.Parameters.MarkSensitive(noEchoKeys...)
+
+	err = stack.setChecksum()
+	if err != nil {
+		return nil, err
+	}
+
+	return stack, nil
+}
+
+// toNoEchoParameterKeys lists the template parameters declared with NoEcho,
+// whose values CloudFormation itself masks.
+func toNoEchoParameterKeys(template []byte) ([]string, error) {
+	var document struct {
+		Parameters map[string]struct {
+			NoEcho interface{} `yaml:"NoEcho"`
+		} `yaml:"Parameters"`
 	}
 
-	checksum, err := CalculateChecksum(stack.Hashable())
+	err := yaml.Unmarshal(template, &document)
 	if err != nil {
 		return nil, err
 	}
 
-	stack.Checksum = checksum
+	keys := make([]string, 0)
 
-	if stack.ArtefactBucket != "" {
-		keyFormat := fmt.Sprintf("stratus/%s/%s/%%s%%s", stack.Name, stack.Checksum)
+	for key, parameter := range document.Parameters {
+		if strings.EqualFold(fmt.Sprint(parameter.NoEcho), "true") {
+			keys = append(keys, key)
+		}
+	}
 
-		policyExtension := filepath.Ext(rawStack.PolicyFile.String())
-		templateExtension := filepath.Ext(rawStack.TemplateFile.String())
+	sort.Strings(keys)
 
-		stack.PolicyKey = fmt.Sprintf(keyFormat, "policy", policyExtension)
-		stack.TemplateKey = fmt.Sprintf(keyFormat, "template", templateExtension)
+	return keys, nil
+}
+
+func fromRawStackAcknowledgements(raw RawStackAcknowledgements) []string {
+	if len(raw) == 0 {
+		return nil
 	}
 
-	return stack, nil
+	slice := make([]string, len(raw))
+
+	for index, rawAcknowledgement := range raw {
+		slice[index] = rawAcknowledgement.String()
+	}
+
+	return slice
 }
 
+// fromRawStackCapabilities starts auto mode with none; Stage adopts the
+// template's required capabilities.
 func fromRawStackCapabilities(raw RawStackCapabilities) []string {
+	if raw.isAuto() {
+		return make([]string, 0)
+	}
+
 	slice := make([]string, len(raw))
 
 	for index, rawCapability := range raw {
@@ -104,13 +187,81 @@ func fromRawStackCapabilities(raw RawStackCapabilities) []string {
 	return slice
 }
 
+func fromRawStackDependencies(raw RawStackDependencies) []string {
+	slice := make([]string, len(raw))
+
+	for index, rawDependency := range raw {
+		slice[index] = rawDependency.String()
+	}
+
+	return slice
+}
+
+func fromRawStackRoleARN(rawConfig *RawConfig, rawStack *RawStack) string {
+	if rawStack.RoleARN != "" {
+		return rawStack.RoleARN.String()
+	}
+
+	return rawConfig.Defaults.RoleARN.String()
+}
+
+// fromRawDuration falls back to the default when the stack leaves a duration
+// unset. Both have already been validated.
+func fromRawDuration(raw, rawDefault String) time.Duration {
+	if raw == "" {
+		raw = rawDefault
+	}
+
+	if raw == "" {
+		return 0
+	}
+
+	duration, _ := time.ParseDuration(raw.String())
+
+	return duration
+}
+
+func fromRawStackNotificationARNs(raw RawStackNotificationARNs) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	slice := make([]string, len(raw))
+
+	for index, rawARN := range raw {
+		slice[index] = rawARN.String()
+	}
+
+	return slice
+}
+
+func fromRawStackRollbackTriggers(
+	raw *RawStackRollbackTriggers,
+) *StackRollbackTriggers {
+	if raw == nil {
+		return nil
+	}
+
+	alarmARNs := make([]string, len(raw.AlarmARNs))
+
+	for index, rawARN := range raw.AlarmARNs {
+		alarmARNs[index] = rawARN.String()
+	}
+
+	return &StackRollbackTriggers{
+		AlarmARNs:         alarmARNs,
+		MonitoringMinutes: raw.MonitoringMinutes.Int64(),
+	}
+}
+
 func fromRawStackParameters(raw RawStackParameters) StackParameters {
 	slice := make(StackParameters, len(raw))
 
 	for index, rawParameter := range raw {
 		slice[index] = &StackParameter{
-			Key:   rawParameter.Key.String(),
-			Value: rawParameter.Value.String(),
+			Key:       rawParameter.Key.String(),
+			Sensitive: rawParameter.Sensitive.Bool(),
+			Value:     rawParameter.Value.String(),
 		}
 	}
 
@@ -129,3 +280,43 @@ func fromRawStackTags(raw RawStackTags) StackTags {
 
 	return slice
 }
+
+// fromRawStackProtections applies the default protections ahead of the
+// stack's own, as any matching protection can block a change.
+func fromRawStackProtections(
+	rawDefaults RawStackProtections,
+	rawStack RawStackProtections,
+) []*StackProtection {
+	slice := make([]*StackProtection, 0, len(rawDefaults)+len(rawStack))
+
+	for _, rawProtections := range []RawStackProtections{rawDefaults, rawStack} {
+		for _, rawProtection := range rawProtections {
+			if rawProtection == nil {
+				continue
+			}
+
+			allow := DefaultProtectionAllow
+			if rawProtection.Allow != nil {
+				allow = make([]string, len(rawProtection.Allow))
+
+				for index, rawAction := range rawProtection.Allow {
+					allow[index] = rawAction.String()
+				}
+			}
+
+			protection := &StackProtection{
+				LogicalID:    rawProtection.LogicalID.String(),
+				ResourceType: rawProtection.ResourceType.String(),
+				Allow:        allow,
+			}
+
+			slice = append(slice, protection)
+		}
+	}
+
+	if len(slice) == 0 {
+		return nil
+	}
+
+	return slice
+}
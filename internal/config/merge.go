@@ -0,0 +1,190 @@
+package config
+
+// Fields are merged the same way whether an environment patches the defaults
+// or a stack, or a stack inherits from the defaults: a field set in the
+// overlay wins, parameters and tags are merged by key, protections accumulate,
+// and other lists are replaced whole. An empty list clears an inherited one.
+
+// inheritDefaults fills in the fields that each stack leaves unset.
+func (raw *RawConfig) inheritDefaults() {
+	for index, rawStack := range raw.Stacks {
+		if rawStack == nil {
+			continue
+		}
+
+		inherited := raw.Defaults.toRawStack()
+		inherited.patch(rawStack)
+
+		inherited.Name = rawStack.Name
+		inherited.AssumeRoleARN = rawStack.AssumeRoleARN
+		inherited.ExternalID = rawStack.ExternalID
+		inherited.SessionName = rawStack.SessionName
+
+		inherited.artefactBucket = rawStack.artefactBucket
+		inherited.origin = rawStack.origin
+
+		raw.Stacks[index] = inherited
+	}
+}
+
+// toRawStack carries over the defaults that a stack can override. Protections
+// and the artefact bucket are left on the defaults, as they apply to every
+// stack.
+func (raw *RawDefaults) toRawStack() *RawStack {
+	return &RawStack{
+		AcknowledgeDestructive: raw.AcknowledgeDestructive,
+
+		Capabilities:          raw.Capabilities,
+		DisableRollback:       raw.DisableRollback,
+		NotificationARNs:      raw.NotificationARNs,
+		Parameters:            raw.Parameters,
+		ParametersFile:        raw.ParametersFile,
+		Region:                raw.Region,
+		RoleARN:               raw.RoleARN,
+		RollbackTriggers:      raw.RollbackTriggers,
+		Tags:                  raw.Tags,
+		TerminationProtection: raw.TerminationProtection,
+
+		PollInterval: raw.PollInterval,
+		Timeout:      raw.Timeout,
+
+		PolicyFile:   raw.PolicyFile,
+		TemplateFile: raw.TemplateFile,
+	}
+}
+
+func (raw *RawDefaults) patch(overlay *RawDefaults) {
+	patchString(&raw.ArtefactBucket, overlay.ArtefactBucket)
+
+	raw.AcknowledgeDestructive = patchStrings(raw.AcknowledgeDestructive, overlay.AcknowledgeDestructive)
+	raw.Protect = append(raw.Protect, overlay.Protect...)
+
+	raw.Capabilities = patchStrings(raw.Capabilities, overlay.Capabilities)
+	patchBool(&raw.DisableRollback, overlay.DisableRollback)
+	raw.NotificationARNs = patchStrings(raw.NotificationARNs, overlay.NotificationARNs)
+	raw.Parameters = patchParameters(raw.Parameters, overlay.Parameters)
+	patchString(&raw.ParametersFile, overlay.ParametersFile)
+	patchString(&raw.Region, overlay.Region)
+	patchString(&raw.RoleARN, overlay.RoleARN)
+	patchRollbackTriggers(&raw.RollbackTriggers, overlay.RollbackTriggers)
+	raw.Tags = patchTags(raw.Tags, overlay.Tags)
+	patchBool(&raw.TerminationProtection, overlay.TerminationProtection)
+
+	patchString(&raw.PollInterval, overlay.PollInterval)
+	patchString(&raw.Timeout, overlay.Timeout)
+
+	patchString(&raw.PolicyFile, overlay.PolicyFile)
+	patchString(&raw.TemplateFile, overlay.TemplateFile)
+
+	patchString(&raw.AssumeRoleARN, overlay.AssumeRoleARN)
+	patchString(&raw.ExternalID, overlay.ExternalID)
+	patchString(&raw.SessionName, overlay.SessionName)
+}
+
+// patch keeps the stack's name, as that is what an overlay is matched on. The
+// role has already been resolved with any overlay applied; see decodeStack.
+func (raw *RawStack) patch(overlay *RawStack) {
+	raw.DependsOn = patchStrings(raw.DependsOn, overlay.DependsOn)
+
+	raw.AcknowledgeDestructive = patchStrings(raw.AcknowledgeDestructive, overlay.AcknowledgeDestructive)
+	raw.Protect = append(raw.Protect, overlay.Protect...)
+
+	raw.Capabilities = patchStrings(raw.Capabilities, overlay.Capabilities)
+	patchBool(&raw.DisableRollback, overlay.DisableRollback)
+	raw.NotificationARNs = patchStrings(raw.NotificationARNs, overlay.NotificationARNs)
+	raw.Parameters = patchParameters(raw.Parameters, overlay.Parameters)
+	patchString(&raw.ParametersFile, overlay.ParametersFile)
+	patchString(&raw.Region, overlay.Region)
+	patchString(&raw.RoleARN, overlay.RoleARN)
+	patchRollbackTriggers(&raw.RollbackTriggers, overlay.RollbackTriggers)
+	raw.Tags = patchTags(raw.Tags, overlay.Tags)
+	patchBool(&raw.TerminationProtection, overlay.TerminationProtection)
+
+	patchString(&raw.PollInterval, overlay.PollInterval)
+	patchString(&raw.Timeout, overlay.Timeout)
+
+	patchString(&raw.PolicyFile, overlay.PolicyFile)
+	patchString(&raw.TemplateFile, overlay.TemplateFile)
+}
+
+func patchBool(raw **Bool, overlay *Bool) {
+	if overlay != nil {
+		*raw = overlay
+	}
+}
+
+func patchRollbackTriggers(raw **RawStackRollbackTriggers, overlay *RawStackRollbackTriggers) {
+	if overlay != nil {
+		*raw = overlay
+	}
+}
+
+func patchString(raw *String, overlay String) {
+	if overlay != "" {
+		*raw = overlay
+	}
+}
+
+// patchStrings replaces a list only if the overlay sets one, so that an
+// explicit empty list clears it.
+func patchStrings[T ~[]String](raw, overlay T) T {
+	if overlay == nil {
+		return raw
+	}
+
+	return overlay
+}
+
+func patchParameters(raw, overlay RawStackParameters) RawStackParameters {
+	return patchByKey(raw, overlay, func(parameter *RawStackParameter) String {
+		return parameter.Key
+	})
+}
+
+func patchTags(raw, overlay RawStackTags) RawStackTags {
+	return patchByKey(raw, overlay, func(tag *RawStackTag) String {
+		return tag.Key
+	})
+}
+
+// patchByKey replaces items in place where the overlay has the same key, and
+// appends the rest in order. Each original item is replaced at most once, so
+// duplicate keys within the overlay are kept for validation to report.
+func patchByKey[T any](raw, overlay []*T, toKey func(*T) String) []*T {
+	if overlay == nil {
+		return raw
+	}
+
+	if raw == nil {
+		return overlay
+	}
+
+	merged := append(make([]*T, 0, len(raw)+len(overlay)), raw...)
+
+	indexes := make(map[String]int, len(raw))
+
+	for index, item := range raw {
+		if item != nil && toKey(item) != "" {
+			indexes[toKey(item)] = index
+		}
+	}
+
+	for _, item := range overlay {
+		if item == nil {
+			merged = append(merged, item)
+			continue
+		}
+
+		index, ok := indexes[toKey(item)]
+		if !ok {
+			merged = append(merged, item)
+			continue
+		}
+
+		merged[index] = item
+
+		delete(indexes, toKey(item))
+	}
+
+	return merged
+}
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	assetKeyFormat = "stratus/assets/%x%s"
+)
+
+const (
+	_ packageKind = iota
+	packageKindFile
+	packageKindNestedTemplate
+	packageKindZip
+)
+
+var (
+	// zipModified is the earliest timestamp zip supports. Every entry uses it so
+	// that archives only change when their contents do.
+	zipModified = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	resourceTypeToPackageableProperties = map[string][]packageableProperty{
+		"AWS::ApiGateway::RestApi": {
+			{Name: "BodyS3Location", kind: packageKindFile, format: toBucketKeyLocation},
+		},
+		"AWS::CloudFormation::Stack": {
+			{Name: "TemplateURL", kind: packageKindNestedTemplate, format: toTemplateURL},
+		},
+		"AWS::Lambda::Function": {
+			{Name: "Code", kind: packageKindZip, format: toS3BucketKeyLocation},
+		},
+		"AWS::Lambda::LayerVersion": {
+			{Name: "Content", kind: packageKindZip, format: toS3BucketKeyLocation},
+		},
+		"AWS::Serverless::Api": {
+			{Name: "DefinitionUri", kind: packageKindFile, format: toS3URI},
+		},
+		"AWS::Serverless::Function": {
+			{Name: "CodeUri", kind: packageKindZip, format: toS3URI},
+		},
+		"AWS::Serverless::LayerVersion": {
+			{Name: "ContentUri", kind: packageKindZip, format: toS3URI},
+		},
+		"AWS::StepFunctions::StateMachine": {
+			{Name: "DefinitionS3Location", kind: packageKindFile, format: toBucketKeyLocation},
+		},
+	}
+)
+
+// StackAsset is a local file or directory referenced by a template, packaged
+// for upload to the artefact bucket.
+type StackAsset struct {
+	Key  string
+	Body []byte
+}
+
+type packageKind int
+
+type packageableProperty struct {
+	Name string
+
+	kind   packageKind
+	format func(bucket, key string) interface{}
+}
+
+type packageableTemplate struct {
+	Resources map[string]struct {
+		Properties map[string]interface{} `yaml:"Properties"`
+		Type       string                 `yaml:"Type"`
+	} `yaml:"Resources"`
+}
+
+type packager struct {
+	assets map[string]*StackAsset
+	bucket string
+}
+
+// packageTemplate uploads nothing itself, but finds local paths referenced by
+// a template and rewrites them to the content-addressed artefact bucket keys
+// that the returned assets will be uploaded to.
+func packageTemplate(
+	bucket string,
+	dir string,
+	template []byte,
+) ([]byte, []*StackAsset, error) {
+	p := &packager{
+		assets: make(map[string]*StackAsset),
+		bucket: bucket,
+	}
+
+	template, err := p.packageTemplate(dir, template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(p.assets) == 0 {
+		return template, nil, nil
+	}
+
+	keys := make([]string, 0, len(p.assets))
+	for key := range p.assets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	assets := make([]*StackAsset, len(keys))
+	for index, key := range keys {
+		assets[index] = p.assets[key]
+	}
+
+	return template, assets, nil
+}
+
+func (p *packager) packageTemplate(dir string, template []byte) ([]byte, error) {
+	var document packageableTemplate
+
+	err := yaml.Unmarshal(template, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	// a path shared by several resources is rewritten everywhere at once, so
+	// later resources find it already done
+	rewritten := make(map[[2]string]struct{})
+
+	ids := make([]string, 0, len(document.Resources))
+	for id := range document.Resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		resource := document.Resources[id]
+
+		for _, property := range resourceTypeToPackageableProperties[resource.Type] {
+			value, ok := resource.Properties[property.Name].(string)
+			if !ok || value == "" || strings.Contains(value, "://") {
+				continue
+			}
+
+			if _, ok := rewritten[[2]string{property.Name, value}]; ok {
+				continue
+			}
+
+			path := value
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+
+			// intrinsic functions are flattened to plain strings by the decoder,
+			// so only paths that exist are treated as local
+			info, err := os.Stat(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if p.bucket == "" {
+				return nil, fmt.Errorf(
+					"resource '%s' property '%s' references local path '%s', which requires an artefactBucket",
+					id,
+					property.Name,
+					value,
+				)
+			}
+
+			asset, err := p.pack(property.kind, path, info)
+			if err != nil {
+				return nil, err
+			}
+
+			p.assets[asset.Key] = asset
+
+			replacement, err := json.Marshal(property.format(p.bucket, asset.Key))
+			if err != nil {
+				return nil, err
+			}
+
+			template, err = replaceTemplateValue(template, property.Name, value, replacement)
+			if err != nil {
+				return nil, fmt.Errorf("resource '%s': %s", id, err)
+			}
+
+			rewritten[[2]string{property.Name, value}] = struct{}{}
+		}
+	}
+
+	return template, nil
+}
+
+func (p *packager) pack(kind packageKind, path string, info os.FileInfo) (*StackAsset, error) {
+	switch kind {
+	case packageKindFile:
+		return packageFile(path, info)
+
+	case packageKindNestedTemplate:
+		return p.packageNestedTemplate(path, info)
+
+	case packageKindZip:
+		return packageZip(path, info)
+
+	default:
+		return nil, fmt.Errorf("package kind %d not recognised", kind)
+	}
+}
+
+func packageFile(path string, info os.FileInfo) (*StackAsset, error) {
+	if info.IsDir() {
+		return nil, fmt.Errorf("'%s' must be a file, not a directory", path)
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStackAsset(body, filepath.Ext(path)), nil
+}
+
+func (p *packager) packageNestedTemplate(path string, info os.FileInfo) (*StackAsset, error) {
+	if info.IsDir() {
+		return nil, fmt.Errorf("'%s' must be a file, not a directory", path)
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = p.packageTemplate(filepath.Dir(path), body)
+	if err != nil {
+		return nil, fmt.Errorf("nested template '%s': %s", path, err)
+	}
+
+	return newStackAsset(body, filepath.Ext(path)), nil
+}
+
+func packageZip(path string, info os.FileInfo) (*StackAsset, error) {
+	extension := strings.ToLower(filepath.Ext(path))
+
+	if !info.IsDir() && (extension == ".jar" || extension == ".zip") {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return newStackAsset(body, extension), nil
+	}
+
+	body, err := zipPath(path, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return newStackAsset(body, ".zip"), nil
+}
+
+func newStackAsset(body []byte, extension string) *StackAsset {
+	return &StackAsset{
+		Key:  fmt.Sprintf(assetKeyFormat, sha256.Sum256(body), extension),
+		Body: body,
+	}
+}
+
+// replaceTemplateValue swaps a property's scalar value for a JSON value, which
+// is also valid YAML flow syntax, leaving the rest of the template untouched.
+func replaceTemplateValue(
+	template []byte,
+	name string,
+	value string,
+	replacement []byte,
+) ([]byte, error) {
+	pattern := regexp.MustCompile(fmt.Sprintf(
+		`(?m)(["']?\b%s["']?[ \t]*:[ \t]*)["']?%s["']?([ \t]*(?:[,}#]|$))`,
+		regexp.QuoteMeta(name),
+		regexp.QuoteMeta(value),
+	))
+
+	if !pattern.Match(template) {
+		return nil, fmt.Errorf("property '%s' value '%s' could not be rewritten", name, value)
+	}
+
+	escaped := bytes.ReplaceAll(replacement, []byte("$"), []byte("$$"))
+
+	return pattern.ReplaceAll(template, append(append([]byte("${1}"), escaped...), "${2}"...)), nil
+}
+
+func zipPath(path string, info os.FileInfo) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	writer := zip.NewWriter(buffer)
+
+	if info.IsDir() {
+		err := filepath.Walk(path, func(file string, fileInfo os.FileInfo, err error) error {
+			if err != nil || fileInfo.IsDir() {
+				return err
+			}
+
+			name, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+
+			return writeZipFile(writer, filepath.ToSlash(name), file, fileInfo)
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := writeZipFile(writer, info.Name(), path, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func writeZipFile(writer *zip.Writer, name, path string, info os.FileInfo) error {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	header := &zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: zipModified,
+	}
+
+	// only the executable bit is kept, so umask and ownership don't matter
+	if info.Mode()&0111 != 0 {
+		header.SetMode(0755)
+	} else {
+		header.SetMode(0644)
+	}
+
+	fileWriter, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = fileWriter.Write(body)
+	return err
+}
+
+func toBucketKeyLocation(bucket, key string) interface{} {
+	return map[string]string{
+		"Bucket": bucket,
+		"Key":    key,
+	}
+}
+
+func toS3BucketKeyLocation(bucket, key string) interface{} {
+	return map[string]string{
+		"S3Bucket": bucket,
+		"S3Key":    key,
+	}
+}
+
+func toS3URI(bucket, key string) interface{} {
+	return fmt.Sprintf("s3://%s/%s", bucket, key)
+}
+
+func toTemplateURL(bucket, key string) interface{} {
+	return fmt.Sprintf("https://s3.amazonaws.com/%s/%s", bucket, key)
+}
@@ -0,0 +1,189 @@
+package config_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+const (
+	packageTemplate = `
+Transform: AWS::Serverless-2016-10-31
+Resources:
+  Function:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: ./src
+      Role: !GetAtt Role.Arn
+  RemoteFunction:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: s3://existing-bucket/code.zip
+  Lambda:
+    Type: AWS::Lambda::Function
+    Properties:
+      Code: './lambda.py' # inline comment
+      Role: !Ref RoleArn
+`
+)
+
+func Test_FromPath_Package(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files := map[string]string{
+		"stratus.yaml": `
+defaults:
+  artefactBucket: bucket
+
+stacks:
+  - name: a
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+		"lambda.py":       "def handler(event, context): pass\n",
+		"policy.json":     "{}",
+		"src/index.js":    "exports.handler = async () => {}\n",
+		"src/lib/util.js": "module.exports = {}\n",
+		"template.yaml":   packageTemplate,
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+	require.NoError(err)
+	require.Len(cfg.Stacks, 1)
+
+	stack := cfg.Stacks[0]
+
+	// loading leaves packaging to the commands that upload or compare templates
+	assert.Equal(packageTemplate, string(stack.Template))
+	assert.Empty(stack.Assets)
+
+	unpackagedChecksum := stack.Checksum
+
+	require.NoError(stack.Package())
+	assert.NotEqual(unpackagedChecksum, stack.Checksum)
+
+	template := string(stack.Template)
+
+	assert.Regexp(
+		regexp.MustCompile(`(?m)^      CodeUri: "s3://bucket/stratus/assets/[0-9a-f]{64}\.zip"$`),
+		template,
+	)
+	assert.Regexp(
+		regexp.MustCompile(`(?m)^      Code: \{"S3Bucket":"bucket","S3Key":"stratus/assets/[0-9a-f]{64}\.zip"\} # inline comment$`),
+		template,
+	)
+	assert.Contains(template, "CodeUri: s3://existing-bucket/code.zip")
+	assert.Contains(template, "Role: !GetAtt Role.Arn")
+	assert.Contains(template, "Role: !Ref RoleArn")
+
+	require.Len(stack.Assets, 2)
+
+	names := make([]string, 0)
+
+	for _, asset := range stack.Assets {
+		assert.Contains(template, asset.Key)
+
+		reader, err := zip.NewReader(bytes.NewReader(asset.Body), int64(len(asset.Body)))
+		require.NoError(err)
+
+		for _, file := range reader.File {
+			names = append(names, file.Name)
+		}
+	}
+
+	assert.ElementsMatch([]string{"index.js", "lambda.py", "lib/util.js"}, names)
+
+	// packaging is deterministic, so the checksum is stable across loads
+	reloaded, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+	require.NoError(err)
+	require.NoError(reloaded.Stacks[0].Package())
+	assert.Equal(stack.Checksum, reloaded.Stacks[0].Checksum)
+
+	// packaging again is a no-op
+	require.NoError(stack.Package())
+	assert.Equal(template, string(stack.Template))
+}
+
+func Test_FromPath_Package_SharedAsset(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files := map[string]string{
+		"stratus.yaml": `
+defaults:
+  artefactBucket: bucket
+
+stacks:
+  - name: a
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+		"policy.json":  "{}",
+		"src/index.js": "exports.handler = async () => {}\n",
+		"template.yaml": `
+Transform: AWS::Serverless-2016-10-31
+Resources:
+  A:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: ./src
+      Handler: index.a
+  B:
+    Type: AWS::Serverless::Function
+    Properties:
+      CodeUri: ./src
+      Handler: index.b
+`,
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+	require.NoError(err)
+
+	stack := cfg.Stacks[0]
+	require.NoError(stack.Package())
+
+	require.Len(stack.Assets, 1)
+
+	pattern := regexp.MustCompile(`(?m)^      CodeUri: "s3://bucket/` + regexp.QuoteMeta(stack.Assets[0].Key) + `"$`)
+	assert.Len(pattern.FindAllString(string(stack.Template), -1), 2)
+	assert.NotContains(string(stack.Template), "./src")
+}
+
+func Test_FromPath_Package_NoArtefactBucket(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files := map[string]string{
+		"stratus.yaml": `
+stacks:
+  - name: a
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+		"lambda.py":     "def handler(event, context): pass\n",
+		"policy.json":   "{}",
+		"src/index.js":  "exports.handler = async () => {}\n",
+		"template.yaml": packageTemplate,
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+	require.NoError(err)
+
+	err = cfg.Stacks[0].Package()
+	require.Error(err)
+	assert.Contains(err.Error(), "stack 'a' template: resource 'Function' property 'CodeUri' references local path './src', which requires an artefactBucket")
+}
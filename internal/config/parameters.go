@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// readParametersFile loads the stack's parameters file, if any, relative to the
+// config file.
+func readParametersFile(path string, rawStack *RawStack) (RawStackParameters, error) {
+	if rawStack.ParametersFile == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(path), rawStack.ParametersFile.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseParametersFile(data)
+}
+
+// parseParametersFile accepts the AWS CLI's list of ParameterKey and
+// ParameterValue pairs, in JSON or YAML, or a plain map of keys to values.
+// Values are kept verbatim, so YAML scalars like yes and 1.10 are not coerced.
+func parseParametersFile(data []byte) (RawStackParameters, error) {
+	var document interface{}
+
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	switch document.(type) {
+	case nil:
+		return make(RawStackParameters, 0), nil
+
+	case []interface{}:
+		return parseParameterList(data)
+
+	case map[interface{}]interface{}:
+		return parseParameterMap(data)
+
+	default:
+		return nil, fmt.Errorf("must be a list of ParameterKey and ParameterValue pairs, or a map of keys to values")
+	}
+}
+
+func parseParameterList(data []byte) (RawStackParameters, error) {
+	var list []*struct {
+		ParameterKey     string `yaml:"ParameterKey"`
+		ParameterValue   string `yaml:"ParameterValue"`
+		ResolvedValue    string `yaml:"ResolvedValue"`
+		UsePreviousValue bool   `yaml:"UsePreviousValue"`
+	}
+
+	err := yaml.UnmarshalStrict(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	slice := make(RawStackParameters, 0, len(list))
+	keys := make(map[string]struct{}, len(list))
+
+	for index, item := range list {
+		if item == nil || item.ParameterKey == "" {
+			return nil, fmt.Errorf("[%d].ParameterKey must not be empty", index)
+		}
+
+		if item.UsePreviousValue {
+			return nil, fmt.Errorf("[%d].UsePreviousValue is not supported", index)
+		}
+
+		if _, ok := keys[item.ParameterKey]; ok {
+			return nil, fmt.Errorf("'%s' is duplicated", item.ParameterKey)
+		}
+
+		keys[item.ParameterKey] = struct{}{}
+
+		slice = append(slice, &RawStackParameter{
+			Key:   String(item.ParameterKey),
+			Value: String(item.ParameterValue),
+		})
+	}
+
+	return slice, nil
+}
+
+func parseParameterMap(data []byte) (RawStackParameters, error) {
+	var values map[string]string
+
+	err := yaml.UnmarshalStrict(data, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	slice := make(RawStackParameters, len(keys))
+
+	for index, key := range keys {
+		slice[index] = &RawStackParameter{
+			Key:   String(key),
+			Value: String(values[key]),
+		}
+	}
+
+	return slice, nil
+}
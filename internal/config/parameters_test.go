@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+func Test_FromPath_ParametersFile(t *testing.T) {
+	testCases := []struct {
+		description    string
+		parametersFile string
+		contents       string
+		expected       config.StackParameters
+	}{
+		{
+			description:    "AWS CLI JSON",
+			parametersFile: "params/{{env:STRATUS_TEST_ENVIRONMENT}}.json",
+			contents: `[
+  {"ParameterKey": "InstanceType", "ParameterValue": "t3.large"},
+  {"ParameterKey": "Version", "ParameterValue": "1.10"}
+]`,
+			expected: config.StackParameters{
+				{Key: "InstanceType", Value: "t3.large"},
+				{Key: "Version", Value: "inline"},
+				{Key: "Environment", Value: "test"},
+			},
+		},
+		{
+			description:    "YAML map",
+			parametersFile: "params/test.yaml",
+			contents: `
+Version: 1.10
+InstanceType: t3.large
+Enabled: yes
+`,
+			expected: config.StackParameters{
+				{Key: "Enabled", Value: "yes"},
+				{Key: "InstanceType", Value: "t3.large"},
+				{Key: "Version", Value: "inline"},
+				{Key: "Environment", Value: "test"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			t.Setenv("STRATUS_TEST_ENVIRONMENT", "test")
+
+			files := map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    parameters:
+      - key: Version
+        value: inline
+      - key: Environment
+        value: test
+    parametersFile: ` + testCase.parametersFile + `
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"params/test.json": testCase.contents,
+				"params/test.yaml": testCase.contents,
+				"policy.json":      "{}",
+				"template.yaml":    "Resources: {}",
+			}
+
+			dir := writeFiles(t, files)
+
+			cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+			require.NoError(err)
+			require.Len(cfg.Stacks, 1)
+
+			assert.Equal(testCase.expected, cfg.Stacks[0].Parameters)
+
+			checksum := cfg.Stacks[0].Checksum
+
+			err = ioutil.WriteFile(
+				filepath.Join(dir, "params", "test.json"),
+				[]byte(`[{"ParameterKey": "InstanceType", "ParameterValue": "t3.xlarge"}]`),
+				0600,
+			)
+			require.NoError(err)
+
+			err = ioutil.WriteFile(
+				filepath.Join(dir, "params", "test.yaml"),
+				[]byte("InstanceType: t3.xlarge"),
+				0600,
+			)
+			require.NoError(err)
+
+			cfg, err = config.FromPath(filepath.Join(dir, "stratus.yaml"))
+			require.NoError(err)
+
+			assert.NotEqual(checksum, cfg.Stacks[0].Checksum)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"path"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+const (
+	// ProtectionActionReplace is a Modify change that may replace the resource.
+	ProtectionActionReplace = "Replace"
+)
+
+var (
+	// DefaultProtectionAllow permits non-destructive changes to a protected
+	// resource when a protection does not list its own actions.
+	DefaultProtectionAllow = []string{
+		cloudformation.ChangeActionAdd,
+		cloudformation.ChangeActionModify,
+	}
+
+	ProtectionActions = []string{
+		cloudformation.ChangeActionAdd,
+		cloudformation.ChangeActionDynamic,
+		cloudformation.ChangeActionImport,
+		cloudformation.ChangeActionModify,
+		cloudformation.ChangeActionRemove,
+		ProtectionActionReplace,
+	}
+)
+
+// StackProtection guards resources that match its logical ID and resource
+// type patterns, which use path.Match syntax. An empty pattern matches all.
+type StackProtection struct {
+	LogicalID    string   `json:"logicalId,omitempty"`
+	ResourceType string   `json:"resourceType,omitempty"`
+	Allow        []string `json:"allow"`
+}
+
+func (protection *StackProtection) Allows(action string) bool {
+	return containsString(protection.Allow, action)
+}
+
+func (protection *StackProtection) Matches(logicalID, resourceType string) bool {
+	return matchesPattern(protection.LogicalID, logicalID) &&
+		matchesPattern(protection.ResourceType, resourceType)
+}
+
+func matchesPattern(pattern, str string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	matched, err := path.Match(pattern, str)
+
+	return err == nil && matched
+}
@@ -1,34 +1,120 @@
 package config
 
 type RawConfig struct {
-	Defaults RawDefaults `json:"defaults"`
-	Stacks   []*RawStack `json:"stacks"`
+	Defaults     RawDefaults     `json:"defaults"`
+	Environments RawEnvironments `json:"environments"`
+	Include      []String        `json:"include"`
+	Stacks       []*RawStack     `json:"stacks"`
 }
 
+// RawDefaults are inherited by every stack that leaves the same field unset.
 type RawDefaults struct {
 	ArtefactBucket String `json:"artefactBucket" yaml:"artefactBucket"`
+
+	AcknowledgeDestructive RawStackAcknowledgements `json:"acknowledgeDestructive" yaml:"acknowledgeDestructive"`
+	Protect                RawStackProtections      `json:"protect"`
+
+	Capabilities          RawStackCapabilities      `json:"capabilities"`
+	DisableRollback       *Bool                     `json:"disableRollback" yaml:"disableRollback"`
+	NotificationARNs      RawStackNotificationARNs  `json:"notificationArns" yaml:"notificationArns"`
+	Parameters            RawStackParameters        `json:"parameters"`
+	ParametersFile        String                    `json:"parametersFile" yaml:"parametersFile"`
+	Region                String                    `json:"region"`
+	RoleARN               String                    `json:"roleArn" yaml:"roleArn"`
+	RollbackTriggers      *RawStackRollbackTriggers `json:"rollbackTriggers" yaml:"rollbackTriggers"`
+	Tags                  RawStackTags              `json:"tags"`
+	TerminationProtection *Bool                     `json:"terminationProtection" yaml:"terminationProtection"`
+
+	PollInterval String `json:"pollInterval" yaml:"pollInterval"`
+	Timeout      String `json:"timeout"`
+
+	PolicyFile   String `json:"policyFile" yaml:"policyFile"`
+	TemplateFile String `json:"templateFile" yaml:"templateFile"`
+
+	AssumeRoleARN String `json:"assumeRoleArn" yaml:"assumeRoleArn"`
+	ExternalID    String `json:"externalId" yaml:"externalId"`
+	SessionName   String `json:"sessionName" yaml:"sessionName"`
 }
 
 type RawStack struct {
 	Name String `json:"name"`
 
-	Capabilities          RawStackCapabilities `json:"capabilities"`
-	Parameters            RawStackParameters   `json:"parameters"`
-	Region                String               `json:"region"`
-	Tags                  RawStackTags         `json:"tags"`
-	TerminationProtection Bool                 `json:"terminationProtection" yaml:"terminationProtection"`
+	DependsOn RawStackDependencies `json:"dependsOn" yaml:"dependsOn"`
+
+	AcknowledgeDestructive RawStackAcknowledgements `json:"acknowledgeDestructive" yaml:"acknowledgeDestructive"`
+	Protect                RawStackProtections      `json:"protect"`
+
+	Capabilities          RawStackCapabilities      `json:"capabilities"`
+	DisableRollback       *Bool                     `json:"disableRollback" yaml:"disableRollback"`
+	NotificationARNs      RawStackNotificationARNs  `json:"notificationArns" yaml:"notificationArns"`
+	Parameters            RawStackParameters        `json:"parameters"`
+	ParametersFile        String                    `json:"parametersFile" yaml:"parametersFile"`
+	Region                String                    `json:"region"`
+	RoleARN               String                    `json:"roleArn" yaml:"roleArn"`
+	RollbackTriggers      *RawStackRollbackTriggers `json:"rollbackTriggers" yaml:"rollbackTriggers"`
+	Tags                  RawStackTags              `json:"tags"`
+	TerminationProtection *Bool                     `json:"terminationProtection" yaml:"terminationProtection"`
+
+	PollInterval String `json:"pollInterval" yaml:"pollInterval"`
+	Timeout      String `json:"timeout"`
 
 	PolicyFile   String `json:"policyFile" yaml:"policyFile"`
 	TemplateFile String `json:"templateFile" yaml:"templateFile"`
+
+	// decoded ahead of the other fields and merged with defaults; see rawScope
+	AssumeRoleARN String `json:"-" yaml:"-"`
+	ExternalID    String `json:"-" yaml:"-"`
+	SessionName   String `json:"-" yaml:"-"`
+
+	// set when the stack comes from an included file; see decoder.include
+	artefactBucket String
+	origin         rawOrigin
+
+	// set until the decoder has resolved the stack's role; see decodeStack
+	document *document
 }
 
+// RawEnvironments are overlays on the defaults and stacks, keyed by the name
+// passed to --env. Only the selected environment is decoded.
+type RawEnvironments map[string]*RawEnvironment
+
+type RawEnvironment struct {
+	Defaults RawDefaults `json:"defaults"`
+	Stacks   []*RawStack `json:"stacks"`
+
+	// decodes the environment on demand; see RawEnvironment.UnmarshalJSON
+	document *document
+}
+
+type RawStackAcknowledgements []String
+
+// RawStackCapabilities lists the capabilities to acknowledge, or is the scalar
+// CapabilitiesAuto to adopt whatever the template requires.
 type RawStackCapabilities []String
 
+type RawStackDependencies []String
+
+type RawStackNotificationARNs []String
+
 type RawStackParameters []*RawStackParameter
 
 type RawStackParameter struct {
-	Key   String `json:"key"`
-	Value String `json:"value"`
+	Key       String `json:"key"`
+	Sensitive Bool   `json:"sensitive"`
+	Value     String `json:"value"`
+}
+
+type RawStackProtections []*RawStackProtection
+
+type RawStackProtection struct {
+	LogicalID    String   `json:"logicalId" yaml:"logicalId"`
+	ResourceType String   `json:"resourceType" yaml:"resourceType"`
+	Allow        []String `json:"allow"`
+}
+
+type RawStackRollbackTriggers struct {
+	AlarmARNs         []String `json:"alarmArns" yaml:"alarmArns"`
+	MonitoringMinutes Int      `json:"monitoringMinutes" yaml:"monitoringMinutes"`
 }
 
 type RawStackTags []*RawStackTag
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// outputReferenceRegexp matches the deferred form of a stack output
+	// placeholder. The form survives a YAML round trip, unlike the original
+	// double-brace syntax.
+	outputReferenceRegexp = regexp.MustCompile(`\$\{stack:([^:{}]+):output:([^:{}]+)\}`)
+)
+
+type OutputReference struct {
+	StackName string
+	OutputKey string
+}
+
+func (reference OutputReference) String() string {
+	return fmt.Sprintf("${stack:%s:output:%s}", reference.StackName, reference.OutputKey)
+}
+
+type OutputLookup func(reference OutputReference) (string, error)
+
+// stackMapper defers resolution of stack output placeholders until the
+// upstream stack has been deployed.
+func stackMapper(placeholder string) (string, error) {
+	slice := strings.Split(placeholder, ":")
+	if len(slice) != 3 || slice[0] == "" || slice[1] != "output" || slice[2] == "" {
+		return "", fmt.Errorf("malformed stack placeholder '%s'", placeholder)
+	}
+
+	reference := OutputReference{
+		StackName: slice[0],
+		OutputKey: slice[2],
+	}
+
+	return reference.String(), nil
+}
+
+func findOutputReferences(str string) []OutputReference {
+	slice := make([]OutputReference, 0)
+
+	for _, match := range outputReferenceRegexp.FindAllStringSubmatch(str, -1) {
+		reference := OutputReference{
+			StackName: match[1],
+			OutputKey: match[2],
+		}
+
+		slice = append(slice, reference)
+	}
+
+	return slice
+}
+
+func resolveOutputReferences(str string, lookup OutputLookup) (string, error) {
+	var lookupErr error
+
+	resolved := outputReferenceRegexp.ReplaceAllStringFunc(str, func(match string) string {
+		if lookupErr != nil {
+			return match
+		}
+
+		submatch := outputReferenceRegexp.FindStringSubmatch(match)
+
+		reference := OutputReference{
+			StackName: submatch[1],
+			OutputKey: submatch[2],
+		}
+
+		value, err := lookup(reference)
+		if err != nil {
+			lookupErr = err
+			return match
+		}
+
+		return value
+	})
+
+	return resolved, lookupErr
+}
+
+// OutputReferences lists the upstream stack outputs referenced by the
+// stack's parameters and tags.
+func (stack *Stack) OutputReferences() []OutputReference {
+	slice := make([]OutputReference, 0)
+
+	for _, parameter := range stack.Parameters {
+		slice = append(slice, findOutputReferences(parameter.Value)...)
+	}
+
+	for _, tag := range stack.Tags {
+		slice = append(slice, findOutputReferences(tag.Value)...)
+	}
+
+	return slice
+}
+
+// ResolveOutputs substitutes upstream stack outputs into the stack's
+// parameters and tags, and recalculates the checksum to match.
+func (stack *Stack) ResolveOutputs(lookup OutputLookup) error {
+	if len(stack.OutputReferences()) == 0 {
+		return nil
+	}
+
+	for _, parameter := range stack.Parameters {
+		value, err := resolveOutputReferences(parameter.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("stack '%s' parameter '%s': %w", stack.Name, parameter.Key, err)
+		}
+
+		parameter.Value = value
+	}
+
+	for _, tag := range stack.Tags {
+		value, err := resolveOutputReferences(tag.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("stack '%s' tag '%s': %w", stack.Name, tag.Key, err)
+		}
+
+		tag.Value = value
+	}
+
+	stack.Parameters.registerSecrets()
+
+	return stack.setChecksum()
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+)
+
+const (
+	defaultSessionName = "stratus"
+)
+
+var (
+	newScopedAWSMapper func(scope Scope) Mapper
+
+	// rawScopeKeys are the fields of namedRawScope, which resolve in the scope
+	// that includes the stack rather than the stack's own
+	rawScopeKeys = []string{"name", "region", "assumeRoleArn", "externalId", "sessionName"}
+)
+
+// Scope identifies the account and region that AWS calls for a stack are made
+// in.
+type Scope struct {
+	Region string
+
+	AssumeRoleARN string
+	ExternalID    string
+	SessionName   string
+}
+
+// AWSConfig returns config that targets the scope's region and assumes its
+// role, if any, using credentials from provider.
+func (scope Scope) AWSConfig(provider client.ConfigProvider) *aws.Config {
+	cfg := aws.NewConfig()
+
+	if scope.Region != "" {
+		cfg = cfg.WithRegion(scope.Region)
+	}
+
+	if scope.AssumeRoleARN != "" {
+		credentials := stscreds.NewCredentials(
+			provider,
+			scope.AssumeRoleARN,
+			func(assumeRole *stscreds.AssumeRoleProvider) {
+				assumeRole.RoleSessionName = scope.SessionName
+
+				if scope.ExternalID != "" {
+					assumeRole.ExternalID = aws.String(scope.ExternalID)
+				}
+			},
+		)
+
+		cfg = cfg.WithCredentials(credentials)
+	}
+
+	return cfg
+}
+
+func (scope Scope) withDefaults(defaults Scope) Scope {
+	scope = defaults.withOverlay(scope)
+
+	if scope.SessionName == "" && scope.AssumeRoleARN != "" {
+		scope.SessionName = defaultSessionName
+	}
+
+	return scope
+}
+
+// withOverlay returns the scope with the fields set in overlay taking
+// precedence. An overlay role brings its own external ID.
+func (scope Scope) withOverlay(overlay Scope) Scope {
+	if overlay.Region != "" {
+		scope.Region = overlay.Region
+	}
+
+	if overlay.AssumeRoleARN != "" {
+		scope.AssumeRoleARN = overlay.AssumeRoleARN
+		scope.ExternalID = overlay.ExternalID
+	}
+
+	if overlay.ExternalID != "" {
+		scope.ExternalID = overlay.ExternalID
+	}
+
+	if overlay.SessionName != "" {
+		scope.SessionName = overlay.SessionName
+	}
+
+	return scope
+}
+
+// rawScope is decoded ahead of the rest of a stack so that the remaining
+// fields can resolve AWS placeholders in the stack's account and region.
+type rawScope struct {
+	Region String `json:"region"`
+
+	AssumeRoleARN String `json:"assumeRoleArn" yaml:"assumeRoleArn"`
+	ExternalID    String `json:"externalId" yaml:"externalId"`
+	SessionName   String `json:"sessionName" yaml:"sessionName"`
+}
+
+// namedRawScope ties a stack's role to its name, so that an environment
+// overlay can be matched up with the stack it patches.
+type namedRawScope struct {
+	rawScope `yaml:",inline"`
+
+	Name String `json:"name"`
+}
+
+func (raw *rawScope) Scope() Scope {
+	return Scope{
+		Region: raw.Region.String(),
+
+		AssumeRoleARN: raw.AssumeRoleARN.String(),
+		ExternalID:    raw.ExternalID.String(),
+		SessionName:   raw.SessionName.String(),
+	}
+}
+
+// UnmarshalJSON defers decoding until the decoder has resolved the stack's
+// role; see decodeStack.
+func (raw *RawStack) UnmarshalJSON(data []byte) (err error) {
+	raw.document, err = newJSONDocument(data)
+
+	return
+}
+
+// UnmarshalYAML defers decoding until the decoder has resolved the stack's
+// role; see decodeStack.
+func (raw *RawStack) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	raw.document, err = newYAMLDocument(unmarshal)
+
+	return
+}
+
+// decodeStack resolves the stack's role ahead of its other fields, so that they
+// resolve AWS placeholders in the stack's account and region.
+func (d *decoder) decodeStack(raw *RawStack) error {
+	if raw == nil || raw.document == nil {
+		return nil
+	}
+
+	type rawStackAlias RawStack
+
+	doc := raw.document
+	raw.document = nil
+
+	err := doc.resolve(d.mapper, rawScopeKeys...)
+	if err != nil {
+		return err
+	}
+
+	var scope namedRawScope
+
+	err = doc.unmarshal(&scope)
+	if err != nil {
+		return err
+	}
+
+	resolved := scope.Scope()
+
+	if stackScope, ok := d.stackScopes[scope.Name.String()]; ok {
+		resolved = resolved.withOverlay(stackScope)
+	}
+
+	resolved = resolved.withDefaults(d.scope)
+
+	// the role is already resolved, so this only resolves the other fields
+	err = doc.resolve(d.withAWSScope(resolved).mapper)
+	if err != nil {
+		return err
+	}
+
+	err = doc.unmarshal((*rawStackAlias)(raw))
+	if err != nil {
+		return err
+	}
+
+	raw.AssumeRoleARN = String(resolved.AssumeRoleARN)
+	raw.ExternalID = String(resolved.ExternalID)
+	raw.SessionName = String(resolved.SessionName)
+
+	return nil
+}
+
+// withAWSScope returns a copy of the decoder that resolves AWS placeholders in
+// the given scope.
+func (d *decoder) withAWSScope(scope Scope) *decoder {
+	scoped := *d
+
+	if newScopedAWSMapper != nil && scope != (Scope{}) {
+		scoped.awsMapper = newScopedAWSMapper(scope)
+	}
+
+	return &scoped
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+type secretPlaceholder struct {
+	SecretID     string
+	JSONKey      string
+	VersionStage string
+}
+
+// parseSecretPlaceholder parses secret-id[:json-key][:version-stage], where
+// the secret ID may be an ARN that contains colons of its own.
+func parseSecretPlaceholder(str string) (*secretPlaceholder, error) {
+	var id string
+
+	if strings.HasPrefix(str, "arn:") {
+		slice := strings.SplitN(str, ":", 8)
+		if len(slice) < 7 {
+			return nil, fmt.Errorf("malformed secret ARN '%s'", str)
+		}
+
+		id = strings.Join(slice[:7], ":")
+
+		if !arn.IsARN(id) {
+			return nil, fmt.Errorf("malformed secret ARN '%s'", id)
+		}
+
+		str = strings.TrimPrefix(strings.TrimPrefix(str, id), ":")
+	} else {
+		slice := strings.SplitN(str, ":", 2)
+
+		id = slice[0]
+
+		str = ""
+		if len(slice) == 2 {
+			str = slice[1]
+		}
+	}
+
+	if id == "" {
+		return nil, fmt.Errorf("secret ID must not be empty")
+	}
+
+	placeholder := &secretPlaceholder{
+		SecretID: id,
+	}
+
+	if str == "" {
+		return placeholder, nil
+	}
+
+	slice := strings.Split(str, ":")
+	if len(slice) > 2 {
+		return nil, fmt.Errorf("malformed secret placeholder suffix '%s'", str)
+	}
+
+	placeholder.JSONKey = slice[0]
+
+	if len(slice) == 2 {
+		placeholder.VersionStage = slice[1]
+	}
+
+	return placeholder, nil
+}
+
+func getSecretValue(
+	client *secretsmanager.SecretsManager,
+	placeholder *secretPlaceholder,
+) (string, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(placeholder.SecretID),
+	}
+
+	if placeholder.VersionStage != "" {
+		input.SetVersionStage(placeholder.VersionStage)
+	}
+
+	output, err := client.GetSecretValue(input)
+	if err != nil {
+		return "", err
+	}
+
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret '%s' has no string value", placeholder.SecretID)
+	}
+
+	if placeholder.JSONKey == "" {
+		return *output.SecretString, nil
+	}
+
+	var document map[string]interface{}
+
+	err = json.Unmarshal([]byte(*output.SecretString), &document)
+	if err != nil {
+		return "", fmt.Errorf("secret '%s' is not a JSON object", placeholder.SecretID)
+	}
+
+	value, ok := document[placeholder.JSONKey]
+	if !ok {
+		return "", fmt.Errorf("secret '%s' has no key '%s'", placeholder.SecretID, placeholder.JSONKey)
+	}
+
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,148 @@
+package config_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/log"
+)
+
+func Test_Stack_RedactsSecrets(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	log.RegisterSecret("test-secret-value")
+
+	stack := &config.Stack{
+		Name: "test-stack-name",
+		Parameters: config.StackParameters{
+			{
+				Key:   "Password",
+				Value: "prefix-test-secret-value",
+			},
+		},
+		Tags: config.StackTags{
+			{
+				Key:   "Token",
+				Value: "test-secret-value",
+			},
+		},
+	}
+
+	data, err := json.Marshal(stack)
+	require.NoError(err)
+
+	for _, str := range []string{string(data), stack.String()} {
+		assert.NotContains(str, "test-secret-value")
+		assert.Contains(str, "prefix-****")
+		assert.Contains(str, "test-stack-name")
+	}
+
+	assert.Equal("prefix-test-secret-value", stack.Parameters[0].Value)
+	assert.Equal("test-secret-value", stack.Tags[0].Value)
+}
+
+func Test_FromPath_SensitiveParameters(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	files := map[string]string{
+		"stratus.yaml": `
+stacks:
+  - name: a
+    parameters:
+      - key: ApiKey
+        sensitive: true
+        value: test-api-key
+      - key: DatabasePassword
+        value: test-database-password
+      - key: Environment
+        value: test-environment
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+		"policy.json": "{}",
+		"template.yaml": `
+Parameters:
+  ApiKey:
+    Type: String
+  DatabasePassword:
+    NoEcho: true
+    Type: String
+  Environment:
+    Type: String
+`,
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+	require.NoError(err)
+	require.Len(cfg.Stacks, 1)
+
+	stack := cfg.Stacks[0]
+
+	assert.True(stack.Parameters[0].Sensitive)
+	assert.True(stack.Parameters[1].Sensitive)
+	assert.False(stack.Parameters[2].Sensitive)
+
+	str := stack.String()
+
+	assert.NotContains(str, "test-api-key")
+	assert.NotContains(str, "test-database-password")
+	assert.Contains(str, "test-environment")
+
+	assert.Equal("****", log.Redact("test-api-key"))
+	assert.Equal("test-database-password", stack.Parameters[1].Value)
+}
+
+func Test_Stack_RedactsTrivialSecretsAsWholeValues(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stack := &config.Stack{
+		Name: "test-stack-name",
+		Parameters: config.StackParameters{
+			{
+				Key:   "Debug",
+				Value: "true",
+			},
+			{
+				Key:   "Pin",
+				Value: "4821",
+			},
+		},
+		Tags: config.StackTags{
+			{
+				Key:   "Public",
+				Value: "true",
+			},
+			{
+				Key:   "Ticket",
+				Value: "OPS-4821",
+			},
+		},
+	}
+
+	stack.Parameters.MarkSensitive("Debug", "Pin")
+
+	data, err := json.Marshal(stack)
+	require.NoError(err)
+
+	// the tag shares the trivial value but isn't masked, unlike the short pin
+	for _, str := range []string{string(data), stack.String()} {
+		assert.Contains(str, `"true"`)
+		assert.Contains(str, "OPS-****")
+		assert.NotContains(str, "4821")
+		assert.Contains(str, "test-stack-name")
+	}
+
+	assert.Equal("true", log.Redact("true"))
+	assert.Equal("****", log.RedactParameterValue("true"))
+	assert.Equal("true", stack.Parameters[0].Value)
+}
@@ -9,8 +9,11 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"gopkg.in/yaml.v2"
+
+	"github.com/72636c/stratus/internal/log"
 )
 
 var (
@@ -54,8 +57,17 @@ func (store *MapperStore) Set(prefix string, mapper Mapper) {
 	store.Unlock()
 }
 
+func (store *MapperStore) Delete(prefix string) {
+	store.Lock()
+	delete(store.fromPrefix, prefix)
+	store.Unlock()
+}
+
 type Mapper func(placeholder string) (string, error)
 
+// mapperLookup finds the mapper for a placeholder prefix.
+type mapperLookup func(prefix string) (Mapper, bool)
+
 func envMapper(placeholder string) (string, error) {
 	_, ok := blockedVariables[strings.ToUpper(placeholder)]
 	if ok {
@@ -70,36 +82,138 @@ func envMapper(placeholder string) (string, error) {
 	return resolved, nil
 }
 
-func newAWSMapper(provider client.ConfigProvider) Mapper {
-	client := ssm.New(provider)
+func newAWSMapper(provider client.ConfigProvider, scope Scope) Mapper {
+	cfg := scope.AWSConfig(provider)
+
+	ssmClient := ssm.New(provider, cfg)
+	secretsManagerClient := secretsmanager.New(provider, cfg)
 
 	return func(placeholder string) (string, error) {
-		if !strings.HasPrefix(placeholder, "ssm:parameter:") {
+		switch {
+		case strings.HasPrefix(placeholder, "ssm:parameter:"):
+			name := strings.TrimPrefix(placeholder, "ssm:parameter:")
+
+			return getParameter(ssmClient, name, false)
+
+		case strings.HasPrefix(placeholder, "ssm:secure-parameter:"):
+			name := strings.TrimPrefix(placeholder, "ssm:secure-parameter:")
+
+			value, err := getParameter(ssmClient, name, true)
+			if err != nil {
+				return "", err
+			}
+
+			log.RegisterSecret(value)
+
+			return value, nil
+
+		case strings.HasPrefix(placeholder, "secretsmanager:"):
+			secret, err := parseSecretPlaceholder(
+				strings.TrimPrefix(placeholder, "secretsmanager:"),
+			)
+			if err != nil {
+				return "", err
+			}
+
+			value, err := getSecretValue(secretsManagerClient, secret)
+			if err != nil {
+				return "", err
+			}
+
+			log.RegisterSecret(value)
+
+			return value, nil
+
+		default:
 			return "", fmt.Errorf("unsupported AWS placeholder '%s'", placeholder)
 		}
+	}
+}
+
+func getParameter(client *ssm.SSM, name string, withDecryption bool) (string, error) {
+	input := &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(withDecryption),
+	}
+
+	output, err := client.GetParameter(input)
+	if err != nil {
+		return "", err
+	}
+
+	return *output.Parameter.Value, nil
+}
+
+// newScopedAWSMapperFactory shares a mapper per scope. The cache is locked as
+// configs may be decoded concurrently.
+func newScopedAWSMapperFactory(provider client.ConfigProvider) func(Scope) Mapper {
+	cache := make(map[Scope]Mapper)
+	cacheLock := new(sync.Mutex)
 
-		name := strings.TrimPrefix(placeholder, "ssm:parameter:")
+	return func(scope Scope) Mapper {
+		cacheLock.Lock()
+		defer cacheLock.Unlock()
 
-		input := &ssm.GetParameterInput{
-			Name:           aws.String(name),
-			WithDecryption: aws.Bool(false),
+		cachedMapper, ok := cache[scope]
+		if ok {
+			return cachedMapper
 		}
 
-		output, err := client.GetParameter(input)
+		mapper := newAWSMapper(provider, scope)
+
+		cache[scope] = mapper
+
+		return mapper
+	}
+}
+
+// offlineAWSMapper checks the syntax of AWS placeholders without resolving
+// them, for commands that make no AWS calls. The placeholder is kept in a
+// deferred form, like a stack output placeholder.
+func offlineAWSMapper(placeholder string) (string, error) {
+	switch {
+	case strings.HasPrefix(placeholder, "ssm:parameter:"),
+		strings.HasPrefix(placeholder, "ssm:secure-parameter:"):
+		// any name is well formed
+
+	case strings.HasPrefix(placeholder, "secretsmanager:"):
+		_, err := parseSecretPlaceholder(
+			strings.TrimPrefix(placeholder, "secretsmanager:"),
+		)
 		if err != nil {
 			return "", err
 		}
 
-		return *output.Parameter.Value, nil
+	default:
+		return "", fmt.Errorf("unsupported AWS placeholder '%s'", placeholder)
 	}
+
+	return fmt.Sprintf("${aws:%s}", placeholder), nil
 }
 
 func Init(provider client.ConfigProvider) {
 	mapperStore.Set("env", envMapper)
+	mapperStore.Set("stack", stackMapper)
+
+	if provider == nil {
+		mapperStore.Delete("aws")
+		newScopedAWSMapper = nil
 
-	if provider != nil {
-		mapperStore.Set("aws", newAWSMapper(provider))
+		return
 	}
+
+	mapperStore.Set("aws", newAWSMapper(provider, Scope{}))
+	newScopedAWSMapper = newScopedAWSMapperFactory(provider)
+}
+
+// InitOffline is like Init, but leaves AWS placeholders unresolved so that a
+// config can be checked without credentials.
+func InitOffline() {
+	mapperStore.Set("env", envMapper)
+	mapperStore.Set("stack", stackMapper)
+	mapperStore.Set("aws", offlineAWSMapper)
+
+	newScopedAWSMapper = nil
 }
 
 func Unmarshal(
@@ -149,6 +263,11 @@ func (stack ResolveStack) String() string {
 }
 
 func Resolve(data string) (string, error) {
+	return resolve(data, mapperStore.Get)
+}
+
+// resolve is like Resolve, but finds mappers with lookup; see decoder.mapper.
+func resolve(data string, lookup mapperLookup) (string, error) {
 	skip := false
 	stack := NewResolveStack()
 
@@ -189,7 +308,7 @@ func Resolve(data string) (string, error) {
 			prefix := slice[0]
 			suffix := slice[1]
 
-			mapper, ok := mapperStore.Get(prefix)
+			mapper, ok := lookup(prefix)
 			if !ok {
 				return "", fmt.Errorf("unsupported placeholder '%s'", str)
 			}
@@ -211,16 +330,42 @@ func Resolve(data string) (string, error) {
 	return stack.String(), nil
 }
 
+// resolveScalar resolves a value, unless the decoder already has.
+func resolveScalar(data string) (string, error) {
+	if strings.HasPrefix(data, resolvedPrefix) {
+		return strings.TrimPrefix(data, resolvedPrefix), nil
+	}
+
+	return Resolve(data)
+}
+
+// resolveJSONScalar is like resolveScalar, but for an encoded JSON value.
+func resolveJSONScalar(data []byte) (string, error) {
+	var str string
+
+	if json.Unmarshal(data, &str) == nil && strings.HasPrefix(str, resolvedPrefix) {
+		encoded, err := json.Marshal(strings.TrimPrefix(str, resolvedPrefix))
+
+		return string(encoded), err
+	}
+
+	return Resolve(string(data))
+}
+
 type Bool bool
 
 func (bit *Bool) Bool() bool {
+	if bit == nil {
+		return false
+	}
+
 	return bool(*bit)
 }
 
 func (bit *Bool) UnmarshalJSON(data []byte) error {
 	type boolAlias Bool
 
-	resolved, err := Resolve(string(data))
+	resolved, err := resolveJSONScalar(data)
 	if err != nil {
 		return err
 	}
@@ -240,7 +385,7 @@ func (bit *Bool) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
-	resolved, err := Resolve(data)
+	resolved, err := resolveScalar(data)
 	if err != nil {
 		return err
 	}
@@ -250,6 +395,45 @@ func (bit *Bool) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return yaml.UnmarshalStrict([]byte(resolved), (*boolAlias)(bit))
 }
 
+type Int int64
+
+func (integer *Int) Int64() int64 {
+	return int64(*integer)
+}
+
+func (integer *Int) UnmarshalJSON(data []byte) error {
+	type intAlias Int
+
+	resolved, err := resolveJSONScalar(data)
+	if err != nil {
+		return err
+	}
+
+	resolved = strings.Trim(resolved, `"`)
+
+	return json.Unmarshal([]byte(resolved), (*intAlias)(integer))
+}
+
+func (integer *Int) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type intAlias Int
+
+	var data string
+
+	err := unmarshal(&data)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveScalar(data)
+	if err != nil {
+		return err
+	}
+
+	resolved = strings.Trim(resolved, `"`)
+
+	return yaml.UnmarshalStrict([]byte(resolved), (*intAlias)(integer))
+}
+
 type String string
 
 func (str *String) String() string {
@@ -269,7 +453,7 @@ func (str *String) StringPointer() *string {
 func (str *String) UnmarshalJSON(data []byte) error {
 	type stringAlias String
 
-	resolved, err := Resolve(string(data))
+	resolved, err := resolveJSONScalar(data)
 	if err != nil {
 		return err
 	}
@@ -287,6 +471,12 @@ func (str *String) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		return err
 	}
 
+	// a resolved value is taken as is, rather than parsed as YAML
+	if strings.HasPrefix(data, resolvedPrefix) {
+		*str = String(strings.TrimPrefix(data, resolvedPrefix))
+		return nil
+	}
+
 	resolved, err := Resolve(data)
 	if err != nil {
 		return err
@@ -294,3 +484,35 @@ func (str *String) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	return yaml.UnmarshalStrict([]byte(resolved), (*stringAlias)(str))
 }
+
+// UnmarshalJSON also accepts the scalar CapabilitiesAuto in place of a list.
+func (capabilities *RawStackCapabilities) UnmarshalJSON(data []byte) error {
+	type capabilitiesAlias RawStackCapabilities
+
+	var str String
+
+	if json.Unmarshal(data, &str) == nil {
+		*capabilities = RawStackCapabilities{str}
+		return nil
+	}
+
+	return json.Unmarshal(data, (*capabilitiesAlias)(capabilities))
+}
+
+// UnmarshalYAML also accepts the scalar CapabilitiesAuto in place of a list.
+func (capabilities *RawStackCapabilities) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type capabilitiesAlias RawStackCapabilities
+
+	var str String
+
+	if unmarshal(&str) == nil {
+		*capabilities = RawStackCapabilities{str}
+		return nil
+	}
+
+	return unmarshal((*capabilitiesAlias)(capabilities))
+}
+
+func (capabilities RawStackCapabilities) isAuto() bool {
+	return len(capabilities) == 1 && capabilities[0] == CapabilitiesAuto
+}
@@ -2,6 +2,9 @@ package config_test
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -147,6 +150,15 @@ func Test_Unmarshal(t *testing.T) {
 				String: "true",
 			},
 		},
+		{
+			description: "YAML stack output placeholder",
+			input:       "bool: yes\nstring: '{{stack:network:output:VpcId}}'",
+			extension:   ".yaml",
+			expected: &Data{
+				Bool:   true,
+				String: "${stack:network:output:VpcId}",
+			},
+		},
 		{
 			description:   "invalid JSON bool",
 			input:         `{"Bool": "tr\"ue", "String": "hello"}`,
@@ -185,3 +197,287 @@ func Test_Unmarshal(t *testing.T) {
 		})
 	}
 }
+
+func Test_Unmarshal_RawConfigScope(t *testing.T) {
+	testCases := []struct {
+		description string
+		input       string
+		extension   string
+	}{
+		{
+			description: "JSON",
+			input: `{
+				"stacks": [
+					{"name": "a", "assumeRoleArn": "arn:aws:iam::111111111111:role/a", "externalId": "x"},
+					{"name": "b", "region": "us-east-1"}
+				],
+				"defaults": {"assumeRoleArn": "arn:aws:iam::222222222222:role/d", "sessionName": "ci"}
+			}`,
+			extension: ".json",
+		},
+		{
+			description: "YAML",
+			input: `
+stacks:
+  - name: a
+    assumeRoleArn: arn:aws:iam::111111111111:role/a
+    externalId: x
+  - name: b
+    region: us-east-1
+defaults:
+  assumeRoleArn: arn:aws:iam::222222222222:role/d
+  sessionName: ci
+`,
+			extension: ".yaml",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var raw *config.RawConfig
+
+			err := config.Unmarshal(testCase.extension, []byte(testCase.input), &raw)
+			require.NoError(err)
+			require.Len(raw.Stacks, 2)
+
+			assert.Equal(config.String("arn:aws:iam::111111111111:role/a"), raw.Stacks[0].AssumeRoleARN)
+			assert.Equal(config.String("x"), raw.Stacks[0].ExternalID)
+			assert.Equal(config.String("ci"), raw.Stacks[0].SessionName)
+
+			assert.Equal(config.String("arn:aws:iam::222222222222:role/d"), raw.Stacks[1].AssumeRoleARN)
+			assert.Equal(config.String(""), raw.Stacks[1].ExternalID)
+			assert.Equal(config.String("ci"), raw.Stacks[1].SessionName)
+			assert.Equal(config.String("us-east-1"), raw.Stacks[1].Region)
+		})
+	}
+}
+
+func Test_FromPath_ScopedAWSPlaceholders(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var (
+		calls    = make(map[string]int)
+		callsMux sync.Mutex
+	)
+
+	config.InitScopedAWSMapper(func(scope config.Scope) config.Mapper {
+		return func(placeholder string) (string, error) {
+			callsMux.Lock()
+			calls[scope.AssumeRoleARN+"|"+placeholder]++
+			callsMux.Unlock()
+
+			if strings.HasPrefix(placeholder, "ssm:parameter:/role/") {
+				return "arn:aws:iam::000000000000:role/" + strings.TrimPrefix(placeholder, "ssm:parameter:/role/"), nil
+			}
+
+			// braces in a resolved value are not placeholders
+			return "{{secret for " + scope.AssumeRoleARN + "}}", nil
+		}
+	})
+	t.Cleanup(func() {
+		config.Init(nil)
+	})
+
+	files := map[string]string{
+		"stratus.yaml": `
+defaults:
+  assumeRoleArn: "{{aws:ssm:parameter:/role/default}}"
+  policyFile: policy.json
+  templateFile: template.yaml
+environments:
+  prod:
+    stacks:
+      - name: b
+        assumeRoleArn: "{{aws:ssm:parameter:/role/b}}"
+stacks:
+  - name: a
+    assumeRoleArn: "{{aws:ssm:parameter:/role/a}}"
+    parameters:
+      - key: Secret
+        value: "{{aws:ssm:parameter:/secret}}"
+  - name: b
+    parameters:
+      - key: Secret
+        value: "{{aws:ssm:parameter:/secret}}"
+  - name: c
+    parameters:
+      - key: Secret
+        value: "{{aws:ssm:parameter:/secret}}"
+`,
+		"policy.json":   "{}",
+		"template.yaml": "Resources: {}",
+	}
+
+	dir := writeFiles(t, files)
+
+	cfg, err := config.FromPathWithEnvironment(filepath.Join(dir, "stratus.yaml"), "prod")
+	require.NoError(err)
+
+	expected := map[string]string{
+		"a": "arn:aws:iam::000000000000:role/a",
+		"b": "arn:aws:iam::000000000000:role/b",
+		"c": "arn:aws:iam::000000000000:role/default",
+	}
+
+	for name, assumeRoleARN := range expected {
+		stack, ok := cfg.Stacks.Find(name)
+		require.True(ok, name)
+
+		assert.Equal(assumeRoleARN, stack.AssumeRoleARN, name)
+		require.Len(stack.Parameters, 1, name)
+		assert.Equal("{{secret for "+assumeRoleARN+"}}", stack.Parameters[0].Value, name)
+	}
+
+	// each placeholder is resolved once, and roles in the including scope
+	assert.Equal(
+		map[string]int{
+			"|ssm:parameter:/role/default":                                 1,
+			"|ssm:parameter:/role/a":                                       1,
+			"|ssm:parameter:/role/b":                                       1,
+			"arn:aws:iam::000000000000:role/a|ssm:parameter:/secret":       1,
+			"arn:aws:iam::000000000000:role/b|ssm:parameter:/secret":       1,
+			"arn:aws:iam::000000000000:role/default|ssm:parameter:/secret": 1,
+		},
+		calls,
+	)
+
+	// decoding leaves the registered mapper in place
+	resolved, err := config.Resolve("{{aws:ssm:parameter:/secret}}")
+	require.NoError(err)
+	assert.Equal("{{secret for }}", resolved)
+}
+
+func Test_FromPath_CapabilitiesAuto(t *testing.T) {
+	testCases := []struct {
+		description   string
+		capabilities  string
+		expectedAuto  bool
+		expectedError string
+	}{
+		{
+			description:  "scalar",
+			capabilities: "auto",
+			expectedAuto: true,
+		},
+		{
+			description:  "list",
+			capabilities: "[CAPABILITY_IAM]",
+			expectedAuto: false,
+		},
+		{
+			description:   "mixed",
+			capabilities:  "[auto, CAPABILITY_IAM]",
+			expectedError: "stacks[0] 'a': capabilities: 'auto' must be given on its own",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			files := map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    capabilities: ` + testCase.capabilities + `
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			}
+
+			dir := writeFiles(t, files)
+
+			cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+
+			if testCase.expectedError != "" {
+				require.Error(err)
+				assert.Contains(err.Error(), testCase.expectedError)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(testCase.expectedAuto, cfg.Stacks[0].AutoCapabilities)
+		})
+	}
+}
+
+func Test_FromPath_Offline(t *testing.T) {
+	config.InitOffline()
+	defer config.Init(nil)
+
+	os.Setenv("STRATUS_TEST_APP", "app")
+
+	testCases := []struct {
+		description   string
+		value         string
+		expected      string
+		expectedError string
+	}{
+		{
+			description: "ssm parameter",
+			value:       "{{aws:ssm:parameter:/{{env:STRATUS_TEST_APP}}/size}}",
+			expected:    "${aws:ssm:parameter:/app/size}",
+		},
+		{
+			description: "ssm secure parameter",
+			value:       "{{aws:ssm:secure-parameter:/app/api-key}}",
+			expected:    "${aws:ssm:secure-parameter:/app/api-key}",
+		},
+		{
+			description: "secrets manager",
+			value:       "prefix-{{aws:secretsmanager:app/database:password}}",
+			expected:    "prefix-${aws:secretsmanager:app/database:password}",
+		},
+		{
+			description:   "malformed secret",
+			value:         "{{aws:secretsmanager:app:key:stage:extra}}",
+			expectedError: "malformed secret placeholder suffix 'key:stage:extra'",
+		},
+		{
+			description:   "unsupported",
+			value:         "{{aws:s3:bucket/key}}",
+			expectedError: "unsupported AWS placeholder 's3:bucket/key'",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			files := map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    parameters:
+      - key: Value
+        value: '` + testCase.value + `'
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			}
+
+			dir := writeFiles(t, files)
+
+			cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+
+			if testCase.expectedError != "" {
+				require.Error(err)
+				assert.Contains(err.Error(), testCase.expectedError)
+				return
+			}
+
+			require.NoError(err)
+			assert.Equal(testCase.expected, cfg.Stacks[0].Parameters[0].Value)
+		})
+	}
+}
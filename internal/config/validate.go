@@ -0,0 +1,410 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	maxMonitoringMinutes = 180
+	maxNotificationARNs  = 5
+	maxRollbackTriggers  = 5
+)
+
+type ValidationError struct {
+	Path    string
+	Stack   string
+	Field   string
+	Message string
+}
+
+func (err *ValidationError) Error() string {
+	builder := new(strings.Builder)
+
+	builder.WriteString(err.Path)
+
+	if err.Stack != "" {
+		fmt.Fprintf(builder, ": %s", err.Stack)
+	}
+
+	if err.Field != "" {
+		fmt.Fprintf(builder, ": %s", err.Field)
+	}
+
+	fmt.Fprintf(builder, ": %s", err.Message)
+
+	return builder.String()
+}
+
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	lines := make([]string, len(errs))
+
+	for index, err := range errs {
+		lines[index] = err.Error()
+	}
+
+	return fmt.Sprintf(
+		"config has %d problem(s):\n%s",
+		len(errs),
+		strings.Join(lines, "\n"),
+	)
+}
+
+type validator struct {
+	errs ValidationErrors
+	path string
+
+	// stackPath is the file that defined the stack being validated, if it
+	// came from an include.
+	stackPath string
+}
+
+// Validate checks a raw config for problems that would otherwise surface as
+// AWS errors, reporting all of them at once.
+func Validate(rawConfig *RawConfig, path string) error {
+	v := &validator{
+		errs: make(ValidationErrors, 0),
+		path: path,
+	}
+
+	v.validateConfig(rawConfig)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	return v.errs
+}
+
+func (v *validator) add(stack, field, format string, arguments ...interface{}) {
+	path := v.path
+	if v.stackPath != "" {
+		path = v.stackPath
+	}
+
+	err := &ValidationError{
+		Path:    path,
+		Stack:   stack,
+		Field:   field,
+		Message: formatMessage(format, arguments...),
+	}
+
+	v.errs = append(v.errs, err)
+}
+
+func (v *validator) validateConfig(rawConfig *RawConfig) {
+	if rawConfig == nil || len(rawConfig.Stacks) == 0 {
+		v.add("", "stacks", "must contain at least one stack")
+		return
+	}
+
+	v.validateProtections("", "defaults.protect", rawConfig.Defaults.Protect)
+	v.validateDuration("", "defaults.pollInterval", rawConfig.Defaults.PollInterval)
+	v.validateDuration("", "defaults.timeout", rawConfig.Defaults.Timeout)
+
+	names := make(map[string]rawOrigin, len(rawConfig.Stacks))
+
+	for index, rawStack := range rawConfig.Stacks {
+		if rawStack == nil {
+			v.add(toStackLabel(index, ""), "", "must not be empty")
+			continue
+		}
+
+		name := rawStack.Name.String()
+		origin := v.enterStack(rawStack, index)
+		label := toStackLabel(origin.index, name)
+
+		if name == "" {
+			v.add(label, "name", "must not be empty")
+		} else if previous, ok := names[name]; ok && previous.path != origin.path {
+			v.add(label, "name", "duplicates stacks[%d] in %s", previous.index, previous.path)
+		} else if ok {
+			v.add(label, "name", "duplicates stacks[%d]", previous.index)
+		} else {
+			names[name] = origin
+		}
+	}
+
+	for index, rawStack := range rawConfig.Stacks {
+		if rawStack == nil {
+			continue
+		}
+
+		origin := v.enterStack(rawStack, index)
+
+		v.validateStack(rawStack, toStackLabel(origin.index, rawStack.Name.String()), names)
+	}
+
+	v.stackPath = ""
+}
+
+// enterStack attributes further problems to the file that defined the stack,
+// and returns where in that file the stack is.
+func (v *validator) enterStack(rawStack *RawStack, index int) rawOrigin {
+	v.stackPath = rawStack.origin.path
+
+	if rawStack.origin.path == "" {
+		return rawOrigin{path: v.path, index: index}
+	}
+
+	return rawStack.origin
+}
+
+func (v *validator) validateStack(rawStack *RawStack, label string, names map[string]rawOrigin) {
+	for _, rawDependency := range rawStack.DependsOn {
+		dependency := rawDependency.String()
+
+		if dependency == rawStack.Name.String() {
+			v.add(label, "dependsOn", "must not contain the stack itself")
+		} else if _, ok := names[dependency]; !ok {
+			v.add(label, "dependsOn", "stack '%s' not found in config", dependency)
+		}
+	}
+
+	if rawStack.AssumeRoleARN != "" && !strings.HasPrefix(rawStack.AssumeRoleARN.String(), "arn:") {
+		v.add(label, "assumeRoleArn", "'%s' is not an ARN", rawStack.AssumeRoleARN.String())
+	}
+
+	if rawStack.RoleARN != "" && !strings.HasPrefix(rawStack.RoleARN.String(), "arn:") {
+		v.add(label, "roleArn", "'%s' is not an ARN", rawStack.RoleARN.String())
+	}
+
+	if rawStack.ExternalID != "" && rawStack.AssumeRoleARN == "" {
+		v.add(label, "externalId", "requires assumeRoleArn")
+	}
+
+	if len(rawStack.NotificationARNs) > maxNotificationARNs {
+		v.add(label, "notificationArns", "must not contain more than %d ARNs", maxNotificationARNs)
+	}
+
+	for _, rawARN := range rawStack.NotificationARNs {
+		if !isServiceARN(rawARN.String(), "sns") {
+			v.add(label, "notificationArns", "'%s' is not an SNS topic ARN", rawARN.String())
+		}
+	}
+
+	if rawStack.RollbackTriggers != nil {
+		triggers := rawStack.RollbackTriggers
+
+		if len(triggers.AlarmARNs) > maxRollbackTriggers {
+			v.add(label, "rollbackTriggers.alarmArns", "must not contain more than %d ARNs", maxRollbackTriggers)
+		}
+
+		for _, rawARN := range triggers.AlarmARNs {
+			if !isServiceARN(rawARN.String(), "cloudwatch") {
+				v.add(label, "rollbackTriggers.alarmArns", "'%s' is not a CloudWatch alarm ARN", rawARN.String())
+			}
+		}
+
+		if triggers.MonitoringMinutes < 0 || triggers.MonitoringMinutes > maxMonitoringMinutes {
+			v.add(
+				label,
+				"rollbackTriggers.monitoringMinutes",
+				"%d is not between 0 and %d",
+				triggers.MonitoringMinutes,
+				maxMonitoringMinutes,
+			)
+		}
+	}
+
+	v.validateProtections(label, "protect", rawStack.Protect)
+	v.validateDuration(label, "pollInterval", rawStack.PollInterval)
+	v.validateDuration(label, "timeout", rawStack.Timeout)
+
+	if !rawStack.Capabilities.isAuto() {
+		v.validateCapabilities(label, rawStack.Capabilities)
+	}
+
+	parameterKeys := make(map[string]struct{}, len(rawStack.Parameters))
+
+	for parameterIndex, rawParameter := range rawStack.Parameters {
+		field := fmt.Sprintf("parameters[%d].key", parameterIndex)
+
+		if rawParameter == nil || rawParameter.Key == "" {
+			v.add(label, field, "must not be empty")
+			continue
+		}
+
+		if _, ok := parameterKeys[rawParameter.Key.String()]; ok {
+			v.add(label, field, "'%s' is duplicated", rawParameter.Key.String())
+		}
+
+		parameterKeys[rawParameter.Key.String()] = struct{}{}
+	}
+
+	tagKeys := make(map[string]struct{}, len(rawStack.Tags))
+
+	for tagIndex, rawTag := range rawStack.Tags {
+		field := fmt.Sprintf("tags[%d].key", tagIndex)
+
+		if rawTag == nil || rawTag.Key == "" {
+			v.add(label, field, "must not be empty")
+			continue
+		}
+
+		if _, ok := tagKeys[rawTag.Key.String()]; ok {
+			v.add(label, field, "'%s' is duplicated", rawTag.Key.String())
+		}
+
+		tagKeys[rawTag.Key.String()] = struct{}{}
+	}
+
+	if rawStack.ParametersFile != "" {
+		parameters, ok := v.readFile(label, "parametersFile", rawStack.ParametersFile.String())
+		if ok {
+			_, err := parseParametersFile(parameters)
+			if err != nil {
+				v.add(label, "parametersFile", "'%s' is not valid: %s", rawStack.ParametersFile.String(), err)
+			}
+		}
+	}
+
+	policy, ok := v.readFile(label, "policyFile", rawStack.PolicyFile.String())
+	if ok && !json.Valid(policy) {
+		v.add(label, "policyFile", "'%s' is not valid JSON", rawStack.PolicyFile.String())
+	}
+
+	template, ok := v.readFile(label, "templateFile", rawStack.TemplateFile.String())
+	if ok {
+		var document interface{}
+
+		err := yaml.Unmarshal(template, &document)
+		if err != nil {
+			v.add(label, "templateFile", "'%s' is not valid JSON or YAML: %s", rawStack.TemplateFile.String(), err)
+		}
+	}
+}
+
+func (v *validator) validateCapabilities(stack string, rawCapabilities RawStackCapabilities) {
+	validCapabilities := cloudformation.Capability_Values()
+
+	for _, rawCapability := range rawCapabilities {
+		if rawCapability == CapabilitiesAuto {
+			v.add(stack, "capabilities", "'%s' must be given on its own", CapabilitiesAuto)
+		} else if !containsString(validCapabilities, rawCapability.String()) {
+			v.add(
+				stack,
+				"capabilities",
+				"'%s' is not one of %s",
+				rawCapability.String(),
+				strings.Join(validCapabilities, ", "),
+			)
+		}
+	}
+}
+
+func (v *validator) validateDuration(stack, field string, raw String) {
+	if raw == "" {
+		return
+	}
+
+	duration, err := time.ParseDuration(raw.String())
+	if err != nil {
+		v.add(stack, field, "'%s' is not a duration, such as 30s or 1h", raw.String())
+	} else if duration <= 0 {
+		v.add(stack, field, "'%s' must be positive", raw.String())
+	}
+}
+
+func (v *validator) validateProtections(
+	stack string,
+	field string,
+	rawProtections RawStackProtections,
+) {
+	for index, rawProtection := range rawProtections {
+		protectionField := fmt.Sprintf("%s[%d]", field, index)
+
+		if rawProtection == nil || (rawProtection.LogicalID == "" && rawProtection.ResourceType == "") {
+			v.add(stack, protectionField, "must set logicalId or resourceType")
+			continue
+		}
+
+		if !isValidPattern(rawProtection.LogicalID.String()) {
+			v.add(stack, protectionField+".logicalId", "'%s' is not a valid pattern", rawProtection.LogicalID.String())
+		}
+
+		if !isValidPattern(rawProtection.ResourceType.String()) {
+			v.add(stack, protectionField+".resourceType", "'%s' is not a valid pattern", rawProtection.ResourceType.String())
+		}
+
+		for _, rawAction := range rawProtection.Allow {
+			if !containsString(ProtectionActions, rawAction.String()) {
+				v.add(
+					stack,
+					protectionField+".allow",
+					"'%s' is not one of %s",
+					rawAction.String(),
+					strings.Join(ProtectionActions, ", "),
+				)
+			}
+		}
+	}
+}
+
+func (v *validator) readFile(stack, field, relativePath string) ([]byte, bool) {
+	if relativePath == "" {
+		v.add(stack, field, "must not be empty")
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(v.path), relativePath))
+	if os.IsNotExist(err) {
+		v.add(stack, field, "'%s' does not exist", relativePath)
+		return nil, false
+	}
+	if err != nil {
+		v.add(stack, field, "'%s' could not be read: %s", relativePath, err)
+		return nil, false
+	}
+
+	return data, true
+}
+
+func isServiceARN(arn, service string) bool {
+	slice := strings.SplitN(arn, ":", 6)
+
+	return len(slice) == 6 && slice[0] == "arn" && slice[2] == service
+}
+
+func isValidPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+
+	return err != path.ErrBadPattern
+}
+
+func toStackLabel(index int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("stacks[%d]", index)
+	}
+
+	return fmt.Sprintf("stacks[%d] '%s'", index, name)
+}
+
+func containsString(slice []string, str string) bool {
+	for _, item := range slice {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatMessage(format string, arguments ...interface{}) string {
+	if len(arguments) == 0 {
+		return format
+	}
+
+	return fmt.Sprintf(format, arguments...)
+}
@@ -0,0 +1,272 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "stratus")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		err := os.MkdirAll(filepath.Dir(path), 0700)
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(path, []byte(contents), 0600)
+		require.NoError(t, err)
+	}
+
+	return dir
+}
+
+func Test_FromPath_Validate(t *testing.T) {
+	testCases := []struct {
+		description    string
+		files          map[string]string
+		expectedErrors []string
+	}{
+		{
+			description: "valid config",
+			files: map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    capabilities: [CAPABILITY_IAM]
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			},
+		},
+		{
+			description: "no stacks",
+			files: map[string]string{
+				"stratus.yaml": "stacks: []",
+			},
+			expectedErrors: []string{
+				"stratus.yaml: stacks: must contain at least one stack",
+			},
+		},
+		{
+			description: "invalid protections",
+			files: map[string]string{
+				"stratus.yaml": `
+defaults:
+  protect:
+    - allow: [Add]
+stacks:
+  - name: a
+    protect:
+      - logicalId: 'Table\'
+        allow: [Delete]
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			},
+			expectedErrors: []string{
+				"config has 3 problem(s)",
+				"stratus.yaml: defaults.protect[0]: must set logicalId or resourceType",
+				"stratus.yaml: stacks[0] 'a': protect[0].logicalId: 'Table\\' is not a valid pattern",
+				"stratus.yaml: stacks[0] 'a': protect[0].allow: 'Delete' is not one of",
+			},
+		},
+		{
+			description: "invalid durations",
+			files: map[string]string{
+				"stratus.yaml": `
+defaults:
+  timeout: 30
+stacks:
+  - name: a
+    pollInterval: -5s
+    timeout: 45m
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			},
+			expectedErrors: []string{
+				"config has 2 problem(s)",
+				"stratus.yaml: defaults.timeout: '30' is not a duration, such as 30s or 1h",
+				"stratus.yaml: stacks[0] 'a': pollInterval: '-5s' must be positive",
+			},
+		},
+		{
+			description: "aggregated problems",
+			files: map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    capabilities: [CAPABILITY_MAGIC]
+    dependsOn: [z]
+    parameters:
+      - key: p
+        value: '1'
+      - key: p
+        value: '2'
+    policyFile: policy.yaml
+    templateFile: missing.yaml
+  - name: a
+    policyFile: policy.yaml
+  - policyFile: policy.yaml
+    templateFile: template.yaml
+    tags:
+      - value: v
+`,
+				"policy.yaml":   "Statement: []",
+				"template.yaml": "Resources: {}",
+			},
+			expectedErrors: []string{
+				"config has 11 problem(s)",
+				"stratus.yaml: stacks[1] 'a': name: duplicates stacks[0]",
+				"stratus.yaml: stacks[2]: name: must not be empty",
+				"stratus.yaml: stacks[0] 'a': dependsOn: stack 'z' not found in config",
+				"stratus.yaml: stacks[0] 'a': capabilities: 'CAPABILITY_MAGIC' is not one of",
+				"stratus.yaml: stacks[0] 'a': parameters[1].key: 'p' is duplicated",
+				"stratus.yaml: stacks[0] 'a': policyFile: 'policy.yaml' is not valid JSON",
+				"stratus.yaml: stacks[0] 'a': templateFile: 'missing.yaml' does not exist",
+				"stratus.yaml: stacks[1] 'a': templateFile: must not be empty",
+				"stratus.yaml: stacks[2]: tags[0].key: must not be empty",
+			},
+		},
+		{
+			description: "valid notifications and rollback triggers",
+			files: map[string]string{
+				"stratus.yaml": `
+defaults:
+  notificationArns:
+    - arn:aws:sns:ap-southeast-2:000000000000:topic
+stacks:
+  - name: a
+    rollbackTriggers:
+      alarmArns:
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:errors
+      monitoringMinutes: 180
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			},
+		},
+		{
+			description: "invalid notifications and rollback triggers",
+			files: map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    notificationArns:
+      - arn:aws:sqs:ap-southeast-2:000000000000:queue
+      - topic
+    rollbackTriggers:
+      alarmArns:
+        - arn:aws:sns:ap-southeast-2:000000000000:topic
+      monitoringMinutes: 181
+    policyFile: policy.json
+    templateFile: template.yaml
+  - name: b
+    notificationArns:
+      - arn:aws:sns:ap-southeast-2:000000000000:1
+      - arn:aws:sns:ap-southeast-2:000000000000:2
+      - arn:aws:sns:ap-southeast-2:000000000000:3
+      - arn:aws:sns:ap-southeast-2:000000000000:4
+      - arn:aws:sns:ap-southeast-2:000000000000:5
+      - arn:aws:sns:ap-southeast-2:000000000000:6
+    rollbackTriggers:
+      alarmArns:
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:1
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:2
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:3
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:4
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:5
+        - arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:6
+      monitoringMinutes: -1
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"template.yaml": "Resources: {}",
+			},
+			expectedErrors: []string{
+				"config has 7 problem(s)",
+				"stratus.yaml: stacks[0] 'a': notificationArns: 'arn:aws:sqs:ap-southeast-2:000000000000:queue' is not an SNS topic ARN",
+				"stratus.yaml: stacks[0] 'a': notificationArns: 'topic' is not an SNS topic ARN",
+				"stratus.yaml: stacks[0] 'a': rollbackTriggers.alarmArns: 'arn:aws:sns:ap-southeast-2:000000000000:topic' is not a CloudWatch alarm ARN",
+				"stratus.yaml: stacks[0] 'a': rollbackTriggers.monitoringMinutes: 181 is not between 0 and 180",
+				"stratus.yaml: stacks[1] 'b': notificationArns: must not contain more than 5 ARNs",
+				"stratus.yaml: stacks[1] 'b': rollbackTriggers.alarmArns: must not contain more than 5 ARNs",
+				"stratus.yaml: stacks[1] 'b': rollbackTriggers.monitoringMinutes: -1 is not between 0 and 180",
+			},
+		},
+		{
+			description: "invalid parameters files",
+			files: map[string]string{
+				"stratus.yaml": `
+stacks:
+  - name: a
+    parametersFile: scalar.json
+    policyFile: policy.json
+    templateFile: template.yaml
+  - name: b
+    parametersFile: previous.json
+    policyFile: policy.json
+    templateFile: template.yaml
+  - name: c
+    parametersFile: missing.json
+    policyFile: policy.json
+    templateFile: template.yaml
+`,
+				"policy.json":   "{}",
+				"previous.json": `[{"ParameterKey": "A", "UsePreviousValue": true}]`,
+				"scalar.json":   `"value"`,
+				"template.yaml": "Resources: {}",
+			},
+			expectedErrors: []string{
+				"config has 3 problem(s)",
+				"stratus.yaml: stacks[0] 'a': parametersFile: 'scalar.json' is not valid: must be a list",
+				"stratus.yaml: stacks[1] 'b': parametersFile: 'previous.json' is not valid: [0].UsePreviousValue is not supported",
+				"stratus.yaml: stacks[2] 'c': parametersFile: 'missing.json' does not exist",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			dir := writeFiles(t, testCase.files)
+
+			cfg, err := config.FromPath(filepath.Join(dir, "stratus.yaml"))
+			if len(testCase.expectedErrors) == 0 {
+				assert.NotNil(cfg)
+				assert.NoError(err)
+				return
+			}
+
+			require.Error(err)
+
+			for _, expectedError := range testCase.expectedErrors {
+				assert.Contains(err.Error(), expectedError)
+			}
+		})
+	}
+}
@@ -5,4 +5,11 @@ type contextKey int
 const (
 	_ contextKey = iota
 	loggerKey
+	allowDestructiveKey
+	pollIntervalKey
+	waitTimeoutKey
+	confirmCancelUpdateKey
+	resourcesToSkipKey
+	outputsRecorderKey
+	autoRecoverKey
 )
@@ -0,0 +1,90 @@
+package context
+
+import (
+	"time"
+)
+
+// AllowDestructive reports whether changes blocked by a stack's protections
+// should be allowed through.
+func AllowDestructive(ctx Context) bool {
+	allow, ok := ctx.Value(allowDestructiveKey).(bool)
+
+	return ok && allow
+}
+
+func WithAllowDestructive(ctx Context, allow bool) Context {
+	return WithValue(ctx, allowDestructiveKey, allow)
+}
+
+// AutoRecover reports whether a stack stuck in a status that blocks change sets
+// should be recovered automatically before staging.
+func AutoRecover(ctx Context) bool {
+	auto, ok := ctx.Value(autoRecoverKey).(bool)
+
+	return ok && auto
+}
+
+func WithAutoRecover(ctx Context, auto bool) Context {
+	return WithValue(ctx, autoRecoverKey, auto)
+}
+
+// ConfirmCancelUpdate reports whether an interrupted stack update should be
+// cancelled and rolled back. Updates carry on if no confirmation is set.
+func ConfirmCancelUpdate(ctx Context, stackName string) bool {
+	confirm, ok := ctx.Value(confirmCancelUpdateKey).(func(string) bool)
+
+	return ok && confirm != nil && confirm(stackName)
+}
+
+func WithConfirmCancelUpdate(ctx Context, confirm func(stackName string) bool) Context {
+	return WithValue(ctx, confirmCancelUpdateKey, confirm)
+}
+
+// RecordOutputs hands a stack's outputs to the context's recorder, if any, so
+// that they can be exported once every stack has run.
+func RecordOutputs(ctx Context, stackName string, outputs map[string]string) {
+	record, ok := ctx.Value(outputsRecorderKey).(func(string, map[string]string))
+	if ok && record != nil {
+		record(stackName, outputs)
+	}
+}
+
+func WithOutputsRecorder(ctx Context, record func(stackName string, outputs map[string]string)) Context {
+	return WithValue(ctx, outputsRecorderKey, record)
+}
+
+// PollInterval returns the CLI override for the longest delay between status
+// checks, or zero if there is none.
+func PollInterval(ctx Context) time.Duration {
+	interval, _ := ctx.Value(pollIntervalKey).(time.Duration)
+
+	return interval
+}
+
+func WithPollInterval(ctx Context, interval time.Duration) Context {
+	return WithValue(ctx, pollIntervalKey, interval)
+}
+
+// ResourcesToSkip lists the logical IDs of resources to leave in place when
+// continuing a failed update rollback.
+func ResourcesToSkip(ctx Context) []string {
+	ids, _ := ctx.Value(resourcesToSkipKey).([]string)
+
+	return ids
+}
+
+func WithResourcesToSkip(ctx Context, ids []string) Context {
+	return WithValue(ctx, resourcesToSkipKey, ids)
+}
+
+// WaitTimeout returns the CLI override for how long to wait on a stack
+// operation, or zero if there is none.
+func WaitTimeout(ctx Context) time.Duration {
+	timeout, _ := ctx.Value(waitTimeoutKey).(time.Duration)
+
+	return timeout
+}
+
+func WithWaitTimeout(ctx Context, timeout time.Duration) Context {
+	return WithValue(ctx, waitTimeoutKey, timeout)
+}
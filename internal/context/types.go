@@ -9,7 +9,13 @@ type (
 )
 
 var (
-	Background  = context.Background
-	WithTimeout = context.WithTimeout
-	WithValue   = context.WithValue
+	DeadlineExceeded = context.DeadlineExceeded
+)
+
+var (
+	Background    = context.Background
+	WithCancel    = context.WithCancel
+	WithTimeout   = context.WithTimeout
+	WithValue     = context.WithValue
+	WithoutCancel = context.WithoutCancel
 )
@@ -0,0 +1,116 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	flushLock sync.Mutex
+
+	// lastFlushed is the buffered logger that most recently wrote output
+	lastFlushed *BufferedLogger
+)
+
+type bufferedEntry struct {
+	title bool
+	write func(Logger)
+}
+
+// BufferedLogger holds log calls in memory until flushed, so the output of
+// concurrently running stacks can be written as contiguous sections.
+type BufferedLogger struct {
+	sync.Mutex
+
+	logger  Logger
+	entries []bufferedEntry
+
+	// section is the most recently flushed title, repeated when a later flush
+	// resumes its section after another logger has written
+	section func(Logger)
+}
+
+func NewBufferedLogger(logger Logger) *BufferedLogger {
+	return &BufferedLogger{
+		Mutex: sync.Mutex{},
+
+		logger:  logger,
+		entries: make([]bufferedEntry, 0),
+	}
+}
+
+func (logger *BufferedLogger) Data(model interface{}) {
+	logger.append(false, func(inner Logger) {
+		inner.Data(model)
+	})
+}
+
+func (logger *BufferedLogger) Title(format string, arguments ...interface{}) {
+	logger.append(true, func(inner Logger) {
+		inner.Title(format, arguments...)
+	})
+}
+
+// Flush writes buffered entries to the underlying logger without interleaving
+// with other buffered loggers.
+func (logger *BufferedLogger) Flush() {
+	logger.Lock()
+	entries := logger.entries
+	logger.entries = make([]bufferedEntry, 0)
+	logger.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	flushLock.Lock()
+	defer flushLock.Unlock()
+
+	if lastFlushed != logger && !entries[0].title && logger.section != nil {
+		logger.section(logger.logger)
+	}
+
+	lastFlushed = logger
+
+	for _, entry := range entries {
+		if entry.title {
+			logger.section = entry.write
+		}
+
+		entry.write(logger.logger)
+	}
+}
+
+// FlushEvery flushes on an interval until the returned function is called, so
+// long-running operations report progress before they finish.
+func (logger *BufferedLogger) FlushEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				logger.Flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (logger *BufferedLogger) append(title bool, write func(Logger)) {
+	logger.Lock()
+	logger.entries = append(logger.entries, bufferedEntry{title: title, write: write})
+	logger.Unlock()
+}
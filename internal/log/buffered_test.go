@@ -0,0 +1,137 @@
+package log_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/72636c/stratus/internal/log"
+)
+
+type recordingLogger struct {
+	sync.Mutex
+	lines []string
+}
+
+func (logger *recordingLogger) Data(model interface{}) {
+	logger.record(fmt.Sprintf("%v", model))
+}
+
+func (logger *recordingLogger) Title(format string, arguments ...interface{}) {
+	logger.record("# " + fmt.Sprintf(format, arguments...))
+}
+
+func (logger *recordingLogger) Lines() []string {
+	logger.Lock()
+	defer logger.Unlock()
+
+	return append([]string(nil), logger.lines...)
+}
+
+func (logger *recordingLogger) record(line string) {
+	logger.Lock()
+	logger.lines = append(logger.lines, line)
+	logger.Unlock()
+}
+
+func Test_BufferedLogger_FlushesInOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := new(recordingLogger)
+
+	a := log.NewBufferedLogger(log.WithStackPrefix(inner, "a"))
+	b := log.NewBufferedLogger(log.WithStackPrefix(inner, "b"))
+
+	a.Title("Stage")
+	b.Title("Stage")
+	a.Data("a1")
+	b.Data("b1")
+	a.Data("a2")
+
+	assert.Empty(inner.Lines())
+
+	b.Flush()
+	a.Flush()
+
+	assert.Equal(
+		[]string{
+			"# b: Stage",
+			"b1",
+			"# a: Stage",
+			"a1",
+			"a2",
+		},
+		inner.Lines(),
+	)
+}
+
+func Test_BufferedLogger_ResumesSection(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := new(recordingLogger)
+
+	a := log.NewBufferedLogger(log.WithStackPrefix(inner, "a"))
+	b := log.NewBufferedLogger(log.WithStackPrefix(inner, "b"))
+
+	a.Title("Wait for stack update")
+	a.Data("a1")
+	a.Flush()
+
+	a.Data("a2")
+	a.Flush()
+
+	b.Title("Stage")
+	b.Flush()
+
+	// another stack has written since, so the section title is repeated
+	a.Data("a3")
+	a.Flush()
+
+	assert.Equal(
+		[]string{
+			"# a: Wait for stack update",
+			"a1",
+			"a2",
+			"# b: Stage",
+			"# a: Wait for stack update",
+			"a3",
+		},
+		inner.Lines(),
+	)
+}
+
+func Test_BufferedLogger_FlushEvery(t *testing.T) {
+	assert := assert.New(t)
+
+	inner := new(recordingLogger)
+
+	logger := log.NewBufferedLogger(inner)
+
+	stop := logger.FlushEvery(time.Millisecond)
+
+	logger.Title("Wait for stack update")
+	logger.Data("in progress")
+
+	deadline := time.Now().Add(time.Second)
+	for len(inner.Lines()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Len(inner.Lines(), 2)
+
+	stop()
+
+	logger.Data("complete")
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(inner.Lines(), 2)
+
+	logger.Flush()
+	assert.Equal(
+		[]string{"# Wait for stack update", "in progress", "complete"},
+		inner.Lines(),
+	)
+}
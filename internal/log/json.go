@@ -0,0 +1,124 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	jsonKindData  = "data"
+	jsonKindTitle = "title"
+
+	jsonTypeObject = "object"
+	jsonTypeText   = "text"
+)
+
+var (
+	JSONLogger = newJSONLogger(os.Stdout)
+)
+
+type jsonEvent struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Stack     string      `json:"stack,omitempty"`
+	Phase     string      `json:"phase,omitempty"`
+	Kind      string      `json:"kind"`
+	Type      string      `json:"type,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+type jsonWriter struct {
+	sync.Mutex
+	encoder *json.Encoder
+}
+
+// jsonLogger writes one JSON object per line, tagging each event with the
+// stack it belongs to and the most recent title as its phase.
+type jsonLogger struct {
+	sync.Mutex
+
+	phase  string
+	stack  string
+	writer *jsonWriter
+}
+
+// NewJSONLogger writes JSON events to the given writer.
+func NewJSONLogger(writer io.Writer) Logger {
+	return newJSONLogger(writer)
+}
+
+func newJSONLogger(writer io.Writer) *jsonLogger {
+	return &jsonLogger{
+		writer: &jsonWriter{
+			encoder: json.NewEncoder(writer),
+		},
+	}
+}
+
+func (logger *jsonLogger) Data(model interface{}) {
+	event := &jsonEvent{
+		Kind: jsonKindData,
+		Type: jsonTypeObject,
+		Data: model,
+	}
+
+	switch typed := model.(type) {
+	case string:
+		event.Type = jsonTypeText
+		event.Data = Redact(typed)
+	case Typed:
+		event.Type = typed.LogType()
+	}
+
+	if event.Type != jsonTypeText && hasSecrets() {
+		redacted, err := redactModel(model)
+		if err != nil {
+			redacted = Redact(fmt.Sprintf("%+v", model))
+		}
+
+		event.Data = redacted
+	}
+
+	logger.write(event)
+}
+
+func (logger *jsonLogger) Title(format string, arguments ...interface{}) {
+	title := formatString(format, arguments...)
+
+	logger.Lock()
+	logger.phase = title
+	logger.Unlock()
+
+	logger.write(&jsonEvent{
+		Kind: jsonKindTitle,
+		Data: title,
+	})
+}
+
+func (logger *jsonLogger) WithStack(name string) Logger {
+	return &jsonLogger{
+		stack:  name,
+		writer: logger.writer,
+	}
+}
+
+func (logger *jsonLogger) write(event *jsonEvent) {
+	logger.Lock()
+	event.Phase = logger.phase
+	event.Stack = logger.stack
+	logger.Unlock()
+
+	event.Timestamp = time.Now().UTC()
+
+	logger.writer.Lock()
+	defer logger.writer.Unlock()
+
+	err := logger.writer.encoder.Encode(event)
+	if err != nil {
+		event.Data = fmt.Sprintf("%+v", event.Data)
+		_ = logger.writer.encoder.Encode(event)
+	}
+}
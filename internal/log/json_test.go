@@ -0,0 +1,142 @@
+package log_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/72636c/stratus/internal/log"
+)
+
+type testEvent struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Stack     string          `json:"stack"`
+	Phase     string          `json:"phase"`
+	Kind      string          `json:"kind"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type testModel struct {
+	Name  string
+	Value string
+}
+
+func (testModel) LogType() string {
+	return "test-model"
+}
+
+// decodeEvents decodes each line of NDJSON output as a single event.
+func decodeEvents(t *testing.T, buffer *bytes.Buffer) []testEvent {
+	events := make([]testEvent, 0)
+
+	scanner := bufio.NewScanner(buffer)
+
+	for scanner.Scan() {
+		var event testEvent
+
+		decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+
+		err := decoder.Decode(&event)
+		require.NoError(t, err, scanner.Text())
+
+		// nothing may follow the object on its line
+		require.False(t, decoder.More(), scanner.Text())
+
+		events = append(events, event)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	return events
+}
+
+func Test_JSONLogger_Events(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	buffer := new(bytes.Buffer)
+	logger := log.NewJSONLogger(buffer)
+
+	first := log.WithStack(logger, "first")
+	second := log.WithStack(logger, "second")
+
+	first.Title("Validate template")
+	first.Data("template is valid")
+	second.Title("Create change set")
+	first.Data(testModel{Name: "Bucket", Value: "multi\nline"})
+	second.Data(map[string]string{"Key": "Value"})
+
+	events := decodeEvents(t, buffer)
+	require.Len(events, 5)
+
+	for _, event := range events {
+		assert.False(event.Timestamp.IsZero())
+	}
+
+	assert.Equal("first", events[0].Stack)
+	assert.Equal("Validate template", events[0].Phase)
+	assert.Equal("title", events[0].Kind)
+	assert.Empty(events[0].Type)
+	assert.JSONEq(`"Validate template"`, string(events[0].Data))
+
+	assert.Equal("first", events[1].Stack)
+	assert.Equal("Validate template", events[1].Phase)
+	assert.Equal("data", events[1].Kind)
+	assert.Equal("text", events[1].Type)
+	assert.JSONEq(`"template is valid"`, string(events[1].Data))
+
+	assert.Equal("second", events[2].Stack)
+	assert.Equal("Create change set", events[2].Phase)
+	assert.Equal("title", events[2].Kind)
+
+	// each stack keeps its own phase
+	assert.Equal("first", events[3].Stack)
+	assert.Equal("Validate template", events[3].Phase)
+	assert.Equal("data", events[3].Kind)
+	assert.Equal("test-model", events[3].Type)
+
+	var model testModel
+	require.NoError(json.Unmarshal(events[3].Data, &model))
+	assert.Equal(testModel{Name: "Bucket", Value: "multi\nline"}, model)
+
+	assert.Equal("second", events[4].Stack)
+	assert.Equal("Create change set", events[4].Phase)
+	assert.Equal("object", events[4].Type)
+	assert.JSONEq(`{"Key":"Value"}`, string(events[4].Data))
+}
+
+func Test_JSONLogger_RedactsEvents(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	log.RegisterSecret("test-json-secret-value")
+
+	buffer := new(bytes.Buffer)
+	logger := log.WithStack(log.NewJSONLogger(buffer), "stack")
+
+	logger.Title("Resolve %s", "test-json-secret-value")
+	logger.Data("using test-json-secret-value")
+	logger.Data(testModel{Name: "Secret", Value: "test-json-secret-value"})
+
+	assert.NotContains(buffer.String(), "test-json-secret-value")
+
+	events := decodeEvents(t, buffer)
+	require.Len(events, 3)
+
+	assert.JSONEq(`"Resolve ****"`, string(events[0].Data))
+
+	assert.Equal("Resolve ****", events[1].Phase)
+	assert.JSONEq(`"using ****"`, string(events[1].Data))
+
+	assert.Equal("test-model", events[2].Type)
+
+	var model testModel
+	require.NoError(json.Unmarshal(events[2].Data, &model))
+	assert.Equal(testModel{Name: "Secret", Value: "****"}, model)
+}
@@ -1,7 +1,6 @@
 package log
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -35,6 +34,65 @@ type Logger interface {
 	Title(format string, arguments ...interface{})
 }
 
+// Coloured models are printed with their own highlighting by the colour
+// logger, in place of their summary.
+type Coloured interface {
+	ColourSummary() string
+}
+
+// Summarised models are printed as a single line by text loggers.
+type Summarised interface {
+	Summary() string
+}
+
+// Typed models report a payload type to structured loggers.
+type Typed interface {
+	LogType() string
+}
+
+type stackScoped interface {
+	WithStack(name string) Logger
+}
+
+// WithStack returns a logger that attributes its output to the named stack,
+// for loggers that support it.
+func WithStack(logger Logger, name string) Logger {
+	scoped, ok := logger.(stackScoped)
+	if !ok {
+		return logger
+	}
+
+	return scoped.WithStack(name)
+}
+
+// WithStackPrefix is like WithStack, but prefixes titles with the stack name
+// for loggers that can't attribute output themselves, so the sections of
+// concurrently running stacks can be told apart.
+func WithStackPrefix(logger Logger, name string) Logger {
+	scoped, ok := logger.(stackScoped)
+	if ok {
+		return scoped.WithStack(name)
+	}
+
+	return &prefixedLogger{
+		logger: logger,
+		prefix: name,
+	}
+}
+
+type prefixedLogger struct {
+	logger Logger
+	prefix string
+}
+
+func (logger *prefixedLogger) Data(model interface{}) {
+	logger.logger.Data(model)
+}
+
+func (logger *prefixedLogger) Title(format string, arguments ...interface{}) {
+	logger.logger.Title("%s: %s", logger.prefix, formatString(format, arguments...))
+}
+
 type colourLogger struct {
 	formatter string
 }
@@ -46,14 +104,19 @@ func newColourLogger() *colourLogger {
 }
 
 func (logger *colourLogger) Data(model interface{}) {
-	if str, ok := model.(string); ok {
+	if coloured, ok := model.(Coloured); ok && logger.formatter != "" {
+		fmt.Println(Redact(coloured.ColourSummary()))
+		return
+	}
+
+	if str, ok := toLine(model); ok {
 		fmt.Println(str)
 		return
 	}
 
 	str, err := encodeYAML(model)
 	if err != nil {
-		fmt.Printf("%+v\n", model)
+		fmt.Println(Redact(fmt.Sprintf("%+v", model)))
 		return
 	}
 
@@ -72,14 +135,14 @@ func (logger *colourLogger) Title(format string, arguments ...interface{}) {
 type standardLogger struct{}
 
 func (logger *standardLogger) Data(model interface{}) {
-	if str, ok := model.(string); ok {
+	if str, ok := toLine(model); ok {
 		fmt.Println(str)
 		return
 	}
 
 	str, err := encodeYAML(model)
 	if err != nil {
-		fmt.Printf("%+v\n", model)
+		fmt.Println(Redact(fmt.Sprintf("%+v", model)))
 		return
 	}
 
@@ -92,12 +155,24 @@ func (logger *standardLogger) Title(format string, arguments ...interface{}) {
 	fmt.Printf("\n%s\n%s\n", title, generateLine(title))
 }
 
+// formatString formats a title, redacting any secret values.
 func formatString(format string, arguments ...interface{}) string {
 	if len(arguments) == 0 {
-		return format
+		return Redact(format)
 	}
 
-	return fmt.Sprintf(format, arguments...)
+	return Redact(fmt.Sprintf(format, arguments...))
+}
+
+func toLine(model interface{}) (string, bool) {
+	switch typed := model.(type) {
+	case string:
+		return Redact(typed), true
+	case Summarised:
+		return Redact(typed.Summary()), true
+	default:
+		return "", false
+	}
 }
 
 func generateLine(str string) string {
@@ -105,15 +180,9 @@ func generateLine(str string) string {
 }
 
 // encodeYAML performs a JSON codec round trip to prevent yaml.Marshal from
-// lowercasing struct field names.
+// lowercasing struct field names, redacting secret values along the way.
 func encodeYAML(model interface{}) (string, error) {
-	jsonData, err := json.Marshal(model)
-	if err != nil {
-		return "", err
-	}
-
-	var jsonModel interface{}
-	err = json.Unmarshal(jsonData, &jsonModel)
+	jsonModel, err := redactModel(model)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,189 @@
+package log
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	redactedValue = "****"
+
+	// minRedactLength is the shortest secret that is masked wherever it appears.
+	// Shorter values are too likely to occur by chance; see isTrivial.
+	minRedactLength = 3
+)
+
+var (
+	// trivialValues are masked only as whole parameter values, as masking them
+	// wherever they appear would corrupt unrelated output.
+	trivialValues = map[string]bool{
+		"false": true,
+		"no":    true,
+		"off":   true,
+		"on":    true,
+		"true":  true,
+		"yes":   true,
+	}
+
+	// parameterValueKeys hold parameter values in structured data, such as the
+	// parameters of a change set.
+	parameterValueKeys = map[string]bool{
+		"ParameterValue": true,
+		"ResolvedValue":  true,
+	}
+)
+
+var (
+	secrets = newSecretRegistry()
+)
+
+type secretRegistry struct {
+	sync.RWMutex
+
+	// values are masked wherever they appear
+	values []string
+
+	// trivialValues are only masked as whole parameter values
+	trivialValues map[string]struct{}
+}
+
+func newSecretRegistry() *secretRegistry {
+	return &secretRegistry{
+		RWMutex:       sync.RWMutex{},
+		values:        make([]string, 0),
+		trivialValues: make(map[string]struct{}),
+	}
+}
+
+// RegisterSecret marks a value as sensitive, so that every logger redacts it
+// from the strings and structures it prints. A trivial value like true or 1 is
+// only masked as a whole parameter value.
+func RegisterSecret(value string) {
+	if value == "" {
+		return
+	}
+
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	if isTrivial(value) {
+		secrets.trivialValues[value] = struct{}{}
+		return
+	}
+
+	for _, existing := range secrets.values {
+		if existing == value {
+			return
+		}
+	}
+
+	secrets.values = append(secrets.values, value)
+
+	// longest first, so a secret containing another is replaced whole
+	sort.SliceStable(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+}
+
+// isTrivial reports whether a value is too short or too common to mask
+// wherever it appears.
+func isTrivial(value string) bool {
+	return len(value) < minRedactLength || trivialValues[strings.ToLower(value)]
+}
+
+// Redact replaces registered secret values in a string.
+func Redact(str string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	for _, value := range secrets.values {
+		str = strings.ReplaceAll(str, value, redactedValue)
+	}
+
+	return str
+}
+
+// RedactParameterValue masks a parameter value that is a registered secret in
+// its entirety, trivial or not, and otherwise redacts it like any string.
+func RedactParameterValue(str string) string {
+	if isSecret(str) {
+		return redactedValue
+	}
+
+	return Redact(str)
+}
+
+func isSecret(str string) bool {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	if _, ok := secrets.trivialValues[str]; ok {
+		return true
+	}
+
+	for _, value := range secrets.values {
+		if value == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasSecrets() bool {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	return len(secrets.values) != 0 || len(secrets.trivialValues) != 0
+}
+
+// redactModel returns a JSON-compatible copy of a model with secret values
+// redacted from every string, including map keys.
+func redactModel(model interface{}) (interface{}, error) {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonModel interface{}
+
+	err = json.Unmarshal(data, &jsonModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return redactValue(jsonModel), nil
+}
+
+func redactValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return Redact(typed)
+
+	case []interface{}:
+		for index, item := range typed {
+			typed[index] = redactValue(item)
+		}
+
+		return typed
+
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(typed))
+
+		for key, item := range typed {
+			if str, ok := item.(string); ok && parameterValueKeys[key] {
+				redacted[Redact(key)] = RedactParameterValue(str)
+				continue
+			}
+
+			redacted[Redact(key)] = redactValue(item)
+		}
+
+		return redacted
+
+	default:
+		return value
+	}
+}
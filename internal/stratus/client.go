@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,13 +19,6 @@ import (
 	"github.com/72636c/stratus/internal/errgroup"
 )
 
-var (
-	defaultOptions = []request.WaiterOption{
-		request.WithWaiterDelay(request.ConstantWaiterDelay(1 * time.Second)),
-		request.WithWaiterMaxAttempts(60 * 60 * 2),
-	}
-)
-
 type Client struct {
 	cfn CloudFormation
 	s3  S3
@@ -37,6 +31,30 @@ func NewClient(cfn CloudFormation, s3 S3) *Client {
 	}
 }
 
+// CancelUpdate cancels a stack update that is in progress, and streams stack
+// events until the rollback settles. It returns the final stack status.
+func (client *Client) CancelUpdate(
+	ctx context.Context,
+	stack *config.Stack,
+) (string, error) {
+	input := &cloudformation.CancelUpdateStackInput{
+		ClientRequestToken: nil,
+		StackName:          aws.String(stack.Name),
+	}
+
+	poll, err := client.newPollStackEvents(ctx, stack)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = client.cfn.CancelUpdateStackWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	return client.waitUntilStackSettled(ctx, stack, poll)
+}
+
 func (client *Client) CreateChangeSet(
 	ctx context.Context,
 	stack *config.Stack,
@@ -49,11 +67,11 @@ func (client *Client) CreateChangeSet(
 		ChangeSetType:         aws.String(ChangeSetTypeUpdate.String()),
 		ClientToken:           nil,
 		Description:           nil,
-		NotificationARNs:      nil,
+		NotificationARNs:      toNotificationARNs(stack.NotificationARNs),
 		Parameters:            toCloudFormationParameters(stack.Parameters),
 		ResourceTypes:         nil,
-		RoleARN:               nil,
-		RollbackConfiguration: nil,
+		RoleARN:               toRoleARN(stack.RoleARN),
+		RollbackConfiguration: toRollbackConfiguration(stack.RollbackTriggers),
 		StackName:             aws.String(stack.Name),
 		Tags:                  toCloudFormationTags(stack.Tags),
 		TemplateBody:          nil,
@@ -68,11 +86,13 @@ func (client *Client) CreateChangeSet(
 	}
 
 	_, err = client.cfn.CreateChangeSetWithContext(ctx, input)
-	if isStackDoesNotExistError(err) {
+	if IsStackDoesNotExistError(err) {
 		name = newChangeSetName(stack.Checksum, ChangeSetTypeCreate)
 		input.SetChangeSetName(name)
 		input.SetChangeSetType(ChangeSetTypeCreate.String())
 		_, err = client.cfn.CreateChangeSetWithContext(ctx, input)
+	} else if isValidationError(err) {
+		err = client.checkRecoverableStatus(ctx, stack, err)
 	}
 	if err != nil {
 		return nil, err
@@ -105,7 +125,7 @@ func (client *Client) DeleteStack(
 	input := &cloudformation.DeleteStackInput{
 		ClientRequestToken: nil,
 		RetainResources:    nil,
-		RoleARN:            nil,
+		RoleARN:            toRoleARN(stack.RoleARN),
 		StackName:          aws.String(stack.Name),
 	}
 
@@ -124,6 +144,92 @@ func (client *Client) DeleteStack(
 	return err
 }
 
+// DetectDrift runs drift detection on a stack and waits for it to finish. The
+// returned drift only lists resources that have been modified or deleted.
+func (client *Client) DetectDrift(
+	ctx context.Context,
+	stack *config.Stack,
+) (*Drift, error) {
+	input := &cloudformation.DetectStackDriftInput{
+		StackName: aws.String(stack.Name),
+	}
+
+	output, err := client.cfn.DetectStackDriftWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := client.waitUntilDriftDetectionComplete(
+		ctx,
+		stack,
+		*output.StackDriftDetectionId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := client.describeResourceDrifts(ctx, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &Drift{
+		StackDriftStatus: aws.StringValue(status.StackDriftStatus),
+		Resources:        resources,
+	}
+
+	return drift, nil
+}
+
+// DescribeSummary reports where a stack stands without changing anything.
+func (client *Client) DescribeSummary(
+	ctx context.Context,
+	stack *config.Stack,
+) (*StackSummary, error) {
+	summary := &StackSummary{
+		Name: stack.Name,
+	}
+
+	description, err := client.describeStack(ctx, stack)
+	if IsStackDoesNotExistError(err) {
+		return summary, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	listOutput, err := client.listChangeSets(ctx, stack)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Exists = true
+	summary.StackStatus = aws.StringValue(description.StackStatus)
+	summary.TerminationProtection = aws.BoolValue(description.EnableTerminationProtection)
+
+	summary.LastUpdatedTime = description.LastUpdatedTime
+	if summary.LastUpdatedTime == nil {
+		summary.LastUpdatedTime = description.CreationTime
+	}
+
+	// unresolved output references leave the checksum over their placeholders
+	if len(stack.OutputReferences()) > 0 {
+		summary.ChecksumUnknown = true
+	} else {
+		checksum := getChangeSetChecksum(aws.StringValue(description.ChangeSetId))
+		summary.ChecksumMatches = checksum != "" && checksum == stack.Checksum
+	}
+
+	for _, changeSetSummary := range listOutput.Summaries {
+		if MatchesChangeSetSummary(stack, changeSetSummary) &&
+			aws.StringValue(changeSetSummary.ExecutionStatus) == cloudformation.ExecutionStatusAvailable {
+			summary.PendingChangeSet = true
+		}
+	}
+
+	return summary, nil
+}
+
 func (client *Client) Diff(
 	ctx context.Context,
 	stack *config.Stack,
@@ -166,10 +272,16 @@ func (client *Client) Diff(
 	diff := &Diff{
 		ChangeSet: describeOutput,
 		New: &StackState{
+			DisableRollback:       aws.Bool(stack.DisableRollback),
+			NotificationARNs:      aws.StringSlice(stack.NotificationARNs),
+			RollbackConfiguration: toRollbackConfiguration(stack.RollbackTriggers),
 			StackPolicy:           newPolicy,
 			TerminationProtection: aws.Bool(stack.TerminationProtection),
 		},
 		Old: &StackState{
+			DisableRollback:       description.DisableRollback,
+			NotificationARNs:      description.NotificationARNs,
+			RollbackConfiguration: description.RollbackConfiguration,
 			StackPolicy:           oldPolicy,
 			TerminationProtection: description.EnableTerminationProtection,
 		},
@@ -186,15 +298,20 @@ func (client *Client) ExecuteChangeSet(
 	executeInput := &cloudformation.ExecuteChangeSetInput{
 		ChangeSetName:      aws.String(name),
 		ClientRequestToken: nil,
+		DisableRollback:    nil,
 		StackName:          aws.String(stack.Name),
 	}
 
+	if stack.DisableRollback {
+		executeInput.SetDisableRollback(true)
+	}
+
 	waitInput := &cloudformation.DescribeStacksInput{
 		NextToken: nil,
 		StackName: aws.String(stack.Name),
 	}
 
-	waiter, err := client.newChangeSetExecuteCompleteWaiter(name)
+	stackWaiter, err := client.newChangeSetExecuteCompleteWaiter(name)
 	if err != nil {
 		return err
 	}
@@ -204,14 +321,16 @@ func (client *Client) ExecuteChangeSet(
 		return err
 	}
 
-	options := append(defaultOptions, toWaiterOption(poll))
-
 	_, err = client.cfn.ExecuteChangeSetWithContext(ctx, executeInput)
 	if err != nil {
 		return err
 	}
 
-	err = waiter(ctx, waitInput, options...)
+	waiter := func(ctx aws.Context, options ...request.WaiterOption) error {
+		return stackWaiter(ctx, waitInput, options...)
+	}
+
+	err = client.wait(ctx, stack, waiter, client.describeStackStatus(stack), toWaiterOption(poll))
 	poll()
 	return err
 }
@@ -221,7 +340,7 @@ func (client *Client) FindExistingChangeSet(
 	stack *config.Stack,
 ) (*cloudformation.DescribeChangeSetOutput, error) {
 	listOutput, err := client.listChangeSets(ctx, stack)
-	if isStackDoesNotExistError(err) {
+	if IsStackDoesNotExistError(err) {
 		return nil, nil
 	}
 	if err != nil {
@@ -270,6 +389,41 @@ func (client *Client) FindExistingChangeSet(
 	return nil, nil
 }
 
+// Recover returns a stuck stack to a status that accepts change sets. A failed
+// update rollback is continued, skipping the context's resources to skip, and
+// a stack that failed to create is deleted. It returns the resulting status.
+func (client *Client) Recover(
+	ctx context.Context,
+	stack *config.Stack,
+) (string, error) {
+	status, err := client.GetStackStatus(ctx, stack)
+	if err != nil {
+		return "", err
+	}
+
+	logger := context.Logger(ctx)
+
+	switch status {
+	case cloudformation.StackStatusUpdateRollbackFailed:
+		logger.Title("Continue update rollback")
+
+		return client.continueUpdateRollback(ctx, stack)
+
+	case cloudformation.StackStatusRollbackComplete:
+		logger.Title("Delete stack that failed to create")
+
+		err = client.DeleteStack(ctx, stack)
+		if err != nil {
+			return "", err
+		}
+
+		return cloudformation.StackStatusDeleteComplete, nil
+
+	default:
+		return status, nil
+	}
+}
+
 func (client *Client) SetStackPolicy(
 	ctx context.Context,
 	stack *config.Stack,
@@ -355,6 +509,20 @@ func (client *Client) UploadArtefacts(
 		return
 	})
 
+	for _, asset := range stack.Assets {
+		assetInput := &s3.PutObjectInput{
+			Body:        bytes.NewReader(asset.Body),
+			Bucket:      aws.String(stack.ArtefactBucket),
+			ContentType: aws.String(toAssetContentType(asset.Key)),
+			Key:         aws.String(asset.Key),
+		}
+
+		group.Go(func() (err error) {
+			_, err = client.s3.PutObjectWithContext(ctx, assetInput)
+			return
+		})
+	}
+
 	return group.Wait()
 }
 
@@ -367,8 +535,6 @@ func (client *Client) ValidateTemplate(
 		TemplateURL:  nil,
 	}
 
-	// TODO: check for insufficient capabilities
-
 	return client.cfn.ValidateTemplateWithContext(ctx, input)
 }
 
@@ -384,19 +550,131 @@ func (client *Client) GetStackStatus(
 	return *output.StackStatus, nil
 }
 
+// checkRecoverableStatus returns a RecoverableStatusError in place of a
+// rejected change set's error if the stack is in a status that Recover can
+// resolve, or the original error otherwise.
+func (client *Client) checkRecoverableStatus(
+	ctx context.Context,
+	stack *config.Stack,
+	cause error,
+) error {
+	status, err := client.GetStackStatus(ctx, stack)
+	if err != nil || !isRecoverableStatus(status) {
+		return cause
+	}
+
+	return &RecoverableStatusError{
+		StackName: stack.Name,
+		Status:    status,
+	}
+}
+
+func (client *Client) continueUpdateRollback(
+	ctx context.Context,
+	stack *config.Stack,
+) (string, error) {
+	input := &cloudformation.ContinueUpdateRollbackInput{
+		ClientRequestToken: nil,
+		ResourcesToSkip:    nil,
+		RoleARN:            toRoleARN(stack.RoleARN),
+		StackName:          aws.String(stack.Name),
+	}
+
+	if ids := context.ResourcesToSkip(ctx); len(ids) != 0 {
+		input.SetResourcesToSkip(aws.StringSlice(ids))
+	}
+
+	poll, err := client.newPollStackEvents(ctx, stack)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = client.cfn.ContinueUpdateRollbackWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	status, err := client.waitUntilStackSettled(ctx, stack, poll)
+	if err != nil {
+		return "", err
+	}
+
+	if status != cloudformation.StackStatusUpdateRollbackComplete {
+		return status, fmt.Errorf("stack '%s' rollback ended with status %s", stack.Name, status)
+	}
+
+	return status, nil
+}
+
+// describeChangeSet merges the changes from every page into the first.
 func (client *Client) describeChangeSet(
 	ctx context.Context,
 	stack *config.Stack,
 	name string,
 ) (*cloudformation.DescribeChangeSetOutput, error) {
-	input := &cloudformation.DescribeChangeSetInput{
-		ChangeSetName: aws.String(name),
-		// TODO: handle pagination
-		NextToken: nil,
-		StackName: aws.String(stack.Name),
+	var (
+		nextToken *string
+		output    *cloudformation.DescribeChangeSetOutput
+	)
+
+	for {
+		input := &cloudformation.DescribeChangeSetInput{
+			ChangeSetName: aws.String(name),
+			NextToken:     nextToken,
+			StackName:     aws.String(stack.Name),
+		}
+
+		page, err := client.cfn.DescribeChangeSetWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		if output == nil {
+			output = page
+		} else {
+			output.Changes = append(output.Changes, page.Changes...)
+		}
+
+		if page.NextToken == nil {
+			output.NextToken = nil
+			return output, nil
+		}
+
+		nextToken = page.NextToken
 	}
+}
+
+func (client *Client) describeResourceDrifts(
+	ctx context.Context,
+	stack *config.Stack,
+) ([]*cloudformation.StackResourceDrift, error) {
+	var nextToken *string
+
+	drifts := make([]*cloudformation.StackResourceDrift, 0)
+
+	for {
+		input := &cloudformation.DescribeStackResourceDriftsInput{
+			NextToken: nextToken,
+			StackName: aws.String(stack.Name),
+			StackResourceDriftStatusFilters: aws.StringSlice([]string{
+				cloudformation.StackResourceDriftStatusDeleted,
+				cloudformation.StackResourceDriftStatusModified,
+			}),
+		}
 
-	return client.cfn.DescribeChangeSetWithContext(ctx, input)
+		page, err := client.cfn.DescribeStackResourceDriftsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		drifts = append(drifts, page.StackResourceDrifts...)
+
+		if page.NextToken == nil {
+			return drifts, nil
+		}
+
+		nextToken = page.NextToken
+	}
 }
 
 func (client *Client) describeStack(
@@ -470,13 +748,63 @@ func (client *Client) listChangeSets(
 	ctx context.Context,
 	stack *config.Stack,
 ) (*cloudformation.ListChangeSetsOutput, error) {
-	input := &cloudformation.ListChangeSetsInput{
-		// TODO: handle pagination
-		NextToken: nil,
-		StackName: aws.String(stack.Name),
+	var nextToken *string
+
+	output := &cloudformation.ListChangeSetsOutput{
+		Summaries: make([]*cloudformation.ChangeSetSummary, 0),
 	}
 
-	return client.cfn.ListChangeSetsWithContext(ctx, input)
+	for {
+		input := &cloudformation.ListChangeSetsInput{
+			NextToken: nextToken,
+			StackName: aws.String(stack.Name),
+		}
+
+		page, err := client.cfn.ListChangeSetsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		output.Summaries = append(output.Summaries, page.Summaries...)
+
+		if page.NextToken == nil {
+			return output, nil
+		}
+
+		nextToken = page.NextToken
+	}
+}
+
+// describeStackEvents returns events most recent first, reading pages until
+// they run out or until done reports that a page reaches events already seen.
+func (client *Client) describeStackEvents(
+	ctx context.Context,
+	stack *config.Stack,
+	done func(page []*cloudformation.StackEvent) bool,
+) ([]*cloudformation.StackEvent, error) {
+	var nextToken *string
+
+	events := make([]*cloudformation.StackEvent, 0)
+
+	for {
+		input := &cloudformation.DescribeStackEventsInput{
+			NextToken: nextToken,
+			StackName: aws.String(stack.Name),
+		}
+
+		page, err := client.cfn.DescribeStackEventsWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, page.StackEvents...)
+
+		if page.NextToken == nil || done(page.StackEvents) {
+			return events, nil
+		}
+
+		nextToken = page.NextToken
+	}
 }
 
 func (client *Client) newChangeSetExecuteCompleteWaiter(
@@ -500,13 +828,12 @@ func (client *Client) newPollStackEvents(
 	ctx context.Context,
 	stack *config.Stack,
 ) (func(), error) {
-	eventsInput := &cloudformation.DescribeStackEventsInput{
-		StackName: aws.String(stack.Name),
-	}
-
-	eventsOutput, err := client.cfn.DescribeStackEventsWithContext(
+	// events are listed newest first, so the first page is enough to tell new
+	// events apart from old ones
+	initialEvents, err := client.describeStackEvents(
 		ctx,
-		eventsInput,
+		stack,
+		func([]*cloudformation.StackEvent) bool { return true },
 	)
 	if err != nil {
 		return nil, err
@@ -514,22 +841,30 @@ func (client *Client) newPollStackEvents(
 
 	logger := context.Logger(ctx)
 
-	eventCache := NewStackEventCache(eventsOutput.StackEvents)
+	eventCache := NewStackEventCache(initialEvents)
+
+	// older pages only hold events that have already been seen
+	hasSeenEvents := func(page []*cloudformation.StackEvent) bool {
+		for _, event := range page {
+			if eventCache.Contains(event) {
+				return true
+			}
+		}
+
+		return false
+	}
 
 	poll := func() {
-		eventsOutput, err := client.cfn.DescribeStackEventsWithContext(
-			ctx,
-			eventsInput,
-		)
+		polledEvents, err := client.describeStackEvents(ctx, stack, hasSeenEvents)
 		if err != nil {
 			// continue without failing request
 			return
 		}
 
-		events := eventCache.Diff(eventsOutput.StackEvents)
+		events := eventCache.Diff(polledEvents)
 
 		for index := len(events) - 1; index >= 0; index-- {
-			logger.Data(formatStackEvent(events[index]))
+			logger.Data(&StackEvent{events[index]})
 		}
 	}
 
@@ -548,10 +883,12 @@ func (client *Client) waitUntilChangeSetCreateComplete(
 		StackName:     aws.String(stack.Name),
 	}
 
-	allOptions := append(defaultOptions, options...)
+	waiter := func(ctx aws.Context, options ...request.WaiterOption) error {
+		return client.cfn.
+			WaitUntilChangeSetCreateCompleteWithContext(ctx, input, options...)
+	}
 
-	return client.cfn.
-		WaitUntilChangeSetCreateCompleteWithContext(ctx, input, allOptions...)
+	return client.wait(ctx, stack, waiter, client.describeChangeSetStatus(stack, name), options...)
 }
 
 func (client *Client) waitUntilStackDeleteComplete(
@@ -564,8 +901,126 @@ func (client *Client) waitUntilStackDeleteComplete(
 		StackName: aws.String(stack.Name),
 	}
 
-	allOptions := append(defaultOptions, options...)
+	waiter := func(ctx aws.Context, options ...request.WaiterOption) error {
+		return client.cfn.
+			WaitUntilStackDeleteCompleteWithContext(ctx, input, options...)
+	}
+
+	return client.wait(ctx, stack, waiter, client.describeStackStatus(stack), options...)
+}
+
+// waitUntilStackSettled polls a stack until it is no longer in progress, as
+// the SDK waiters treat rollbacks as failures.
+func (client *Client) waitUntilStackSettled(
+	ctx context.Context,
+	stack *config.Stack,
+	poll func(),
+) (string, error) {
+	settings := newWaitSettings(ctx, stack)
+
+	waitCtx, cancel := context.WithTimeout(ctx, settings.timeout)
+	defer cancel()
+
+	describeStatus := client.describeStackStatus(stack)
+
+	var status string
+
+	for attempt := 1; ; attempt++ {
+		current, err := describeStatus(waitCtx)
+		if err != nil && isTimedOut(ctx, waitCtx) {
+			return "", &TimeoutError{
+				StackName: stack.Name,
+				Status:    status,
+				Timeout:   settings.timeout,
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		status = current
+
+		poll()
+
+		if !strings.HasSuffix(status, "_IN_PROGRESS") {
+			return status, nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if isTimedOut(ctx, waitCtx) {
+				return "", &TimeoutError{
+					StackName: stack.Name,
+					Status:    status,
+					Timeout:   settings.timeout,
+				}
+			}
+
+			return "", ctx.Err()
+
+		case <-time.After(settings.delay(attempt)):
+		}
+	}
+}
+
+// waitUntilDriftDetectionComplete polls a drift detection until it finishes,
+// as the SDK has no waiter for it.
+func (client *Client) waitUntilDriftDetectionComplete(
+	ctx context.Context,
+	stack *config.Stack,
+	id string,
+) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	input := &cloudformation.DescribeStackDriftDetectionStatusInput{
+		StackDriftDetectionId: aws.String(id),
+	}
+
+	settings := newWaitSettings(ctx, stack)
+
+	waitCtx, cancel := context.WithTimeout(ctx, settings.timeout)
+	defer cancel()
 
-	return client.cfn.
-		WaitUntilStackDeleteCompleteWithContext(ctx, input, allOptions...)
+	var status string
+
+	for attempt := 1; ; attempt++ {
+		output, err := client.cfn.
+			DescribeStackDriftDetectionStatusWithContext(waitCtx, input)
+		if err != nil && isTimedOut(ctx, waitCtx) {
+			return nil, &TimeoutError{
+				StackName: stack.Name,
+				Status:    status,
+				Timeout:   settings.timeout,
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		status = aws.StringValue(output.DetectionStatus)
+
+		switch aws.StringValue(output.DetectionStatus) {
+		case cloudformation.StackDriftDetectionStatusDetectionComplete:
+			return output, nil
+
+		case cloudformation.StackDriftDetectionStatusDetectionFailed:
+			return nil, fmt.Errorf(
+				"drift detection failed: %s",
+				aws.StringValue(output.DetectionStatusReason),
+			)
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if isTimedOut(ctx, waitCtx) {
+				return nil, &TimeoutError{
+					StackName: stack.Name,
+					Status:    status,
+					Timeout:   settings.timeout,
+				}
+			}
+
+			return nil, ctx.Err()
+
+		case <-time.After(settings.delay(attempt)):
+		}
+	}
 }
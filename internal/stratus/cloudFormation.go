@@ -8,10 +8,23 @@ import (
 
 var (
 	_ CloudFormation = new(cloudformation.CloudFormation)
+	_ CloudFormation = new(CloudFormationFake)
 	_ CloudFormation = new(CloudFormationMock)
 )
 
 type CloudFormation interface {
+	CancelUpdateStackWithContext(
+		aws.Context,
+		*cloudformation.CancelUpdateStackInput,
+		...request.Option,
+	) (*cloudformation.CancelUpdateStackOutput, error)
+
+	ContinueUpdateRollbackWithContext(
+		aws.Context,
+		*cloudformation.ContinueUpdateRollbackInput,
+		...request.Option,
+	) (*cloudformation.ContinueUpdateRollbackOutput, error)
+
 	CreateChangeSetWithContext(
 		aws.Context,
 		*cloudformation.CreateChangeSetInput,
@@ -30,18 +43,36 @@ type CloudFormation interface {
 		...request.Option,
 	) (*cloudformation.DescribeChangeSetOutput, error)
 
+	DescribeStackDriftDetectionStatusWithContext(
+		aws.Context,
+		*cloudformation.DescribeStackDriftDetectionStatusInput,
+		...request.Option,
+	) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error)
+
 	DescribeStackEventsWithContext(
 		aws.Context,
 		*cloudformation.DescribeStackEventsInput,
 		...request.Option,
 	) (*cloudformation.DescribeStackEventsOutput, error)
 
+	DescribeStackResourceDriftsWithContext(
+		aws.Context,
+		*cloudformation.DescribeStackResourceDriftsInput,
+		...request.Option,
+	) (*cloudformation.DescribeStackResourceDriftsOutput, error)
+
 	DescribeStacksWithContext(
 		aws.Context,
 		*cloudformation.DescribeStacksInput,
 		...request.Option,
 	) (*cloudformation.DescribeStacksOutput, error)
 
+	DetectStackDriftWithContext(
+		aws.Context,
+		*cloudformation.DetectStackDriftInput,
+		...request.Option,
+	) (*cloudformation.DetectStackDriftOutput, error)
+
 	ExecuteChangeSetWithContext(
 		aws.Context,
 		*cloudformation.ExecuteChangeSetInput,
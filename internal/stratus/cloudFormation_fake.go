@@ -0,0 +1,1321 @@
+package stratus
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	fakeAccountID = "000000000000"
+	fakeRegion    = "fake-region-1"
+)
+
+// CloudFormationFake is a stateful, in-memory stand-in for CloudFormation.
+// Change sets complete and execute synchronously, so waiters return as soon
+// as they are called.
+type CloudFormationFake struct {
+	sync.Mutex
+
+	counter         int
+	driftDetections map[string]*cloudformation.DescribeStackDriftDetectionStatusOutput
+	s3              *S3Fake
+	stacks          map[string]*fakeStack
+}
+
+type fakeStack struct {
+	id     string
+	name   string
+	status string
+
+	capabilities          []*string
+	disableRollback       bool
+	notificationARNs      []*string
+	parameters            []*cloudformation.Parameter
+	policy                *string
+	rollbackConfiguration *cloudformation.RollbackConfiguration
+	tags                  []*cloudformation.Tag
+	template              string
+	terminationProtection bool
+
+	changeSetID    *string
+	changeSets     []*fakeChangeSet
+	events         []*cloudformation.StackEvent
+	resourceDrifts []*cloudformation.StackResourceDrift
+
+	creationTime    time.Time
+	lastUpdatedTime *time.Time
+}
+
+type fakeChangeSet struct {
+	id   string
+	name string
+
+	changeSetType   string
+	executionStatus string
+	status          string
+	statusReason    *string
+
+	capabilities          []*string
+	changes               []*cloudformation.Change
+	notificationARNs      []*string
+	parameters            []*cloudformation.Parameter
+	rollbackConfiguration *cloudformation.RollbackConfiguration
+	tags                  []*cloudformation.Tag
+	template              string
+
+	creationTime time.Time
+}
+
+type fakeTemplate struct {
+	Outputs    map[string]fakeTemplateOutput    `yaml:"Outputs"`
+	Parameters map[string]fakeTemplateParameter `yaml:"Parameters"`
+	Resources  map[string]fakeTemplateResource  `yaml:"Resources"`
+	Transform  interface{}                      `yaml:"Transform"`
+}
+
+type fakeTemplateOutput struct {
+	Value interface{} `yaml:"Value"`
+}
+
+type fakeTemplateParameter struct {
+	Default     interface{} `yaml:"Default"`
+	Description string      `yaml:"Description"`
+	NoEcho      interface{} `yaml:"NoEcho"`
+}
+
+type fakeTemplateResource struct {
+	Properties map[string]interface{} `yaml:"Properties"`
+	Type       string                 `yaml:"Type"`
+}
+
+// NewCloudFormationFake creates an empty fake. Template and policy URLs are
+// read from s3, which may be nil if only inline bodies are used.
+func NewCloudFormationFake(s3 *S3Fake) *CloudFormationFake {
+	return &CloudFormationFake{
+		Mutex: sync.Mutex{},
+
+		driftDetections: make(map[string]*cloudformation.DescribeStackDriftDetectionStatusOutput),
+		s3:              s3,
+		stacks:          make(map[string]*fakeStack),
+	}
+}
+
+// SetResourceDrifts simulates out-of-band changes to a stack's resources,
+// which subsequent drift detections will report.
+func (client *CloudFormationFake) SetResourceDrifts(
+	stackName string,
+	drifts ...*cloudformation.StackResourceDrift,
+) error {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(aws.String(stackName))
+	if err != nil {
+		return err
+	}
+
+	stack.resourceDrifts = drifts
+
+	return nil
+}
+
+// SetStackStatus simulates a stack operation that ended in the given status,
+// such as a failed rollback.
+func (client *CloudFormationFake) SetStackStatus(stackName, status string) error {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(aws.String(stackName))
+	if err != nil {
+		return err
+	}
+
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", status)
+
+	stack.status = status
+
+	return nil
+}
+
+func (client *CloudFormationFake) CancelUpdateStackWithContext(
+	_ aws.Context,
+	input *cloudformation.CancelUpdateStackInput,
+	_ ...request.Option,
+) (*cloudformation.CancelUpdateStackOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if stack.status != cloudformation.StackStatusUpdateInProgress {
+		return nil, awserr.New(
+			"ValidationError",
+			fmt.Sprintf(
+				"CancelUpdateStack cannot be called from current stack status %s",
+				stack.status,
+			),
+			nil,
+		)
+	}
+
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", cloudformation.StackStatusUpdateRollbackInProgress)
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", cloudformation.StackStatusUpdateRollbackComplete)
+
+	stack.status = cloudformation.StackStatusUpdateRollbackComplete
+
+	return new(cloudformation.CancelUpdateStackOutput), nil
+}
+
+func (client *CloudFormationFake) ContinueUpdateRollbackWithContext(
+	_ aws.Context,
+	input *cloudformation.ContinueUpdateRollbackInput,
+	_ ...request.Option,
+) (*cloudformation.ContinueUpdateRollbackOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if stack.status != cloudformation.StackStatusUpdateRollbackFailed {
+		return nil, awserr.New(
+			"ValidationError",
+			fmt.Sprintf(
+				"Stack [%s] does not have a status of UPDATE_ROLLBACK_FAILED",
+				stack.name,
+			),
+			nil,
+		)
+	}
+
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", cloudformation.StackStatusUpdateRollbackInProgress)
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", cloudformation.StackStatusUpdateRollbackComplete)
+
+	stack.status = cloudformation.StackStatusUpdateRollbackComplete
+
+	return new(cloudformation.ContinueUpdateRollbackOutput), nil
+}
+
+func (client *CloudFormationFake) CreateChangeSetWithContext(
+	_ aws.Context,
+	input *cloudformation.CreateChangeSetInput,
+	_ ...request.Option,
+) (*cloudformation.CreateChangeSetOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	name := aws.StringValue(input.StackName)
+
+	template, err := client.readObject(input.TemplateBody, input.TemplateURL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseFakeTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkFakeCapabilities(parsed, input.Capabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	stack, ok := client.stacks[name]
+
+	switch aws.StringValue(input.ChangeSetType) {
+	case cloudformation.ChangeSetTypeCreate:
+		if ok && stack.status != cloudformation.StackStatusReviewInProgress {
+			return nil, awserr.New(
+				cloudformation.ErrCodeAlreadyExistsException,
+				fmt.Sprintf("Stack [%s] already exists", name),
+				nil,
+			)
+		}
+
+		if !ok {
+			stack = client.newStack(name)
+			client.stacks[name] = stack
+		}
+
+	default:
+		// stacks that have never been executed are treated as absent, which
+		// matches the create fallback in Client.CreateChangeSet.
+		if !ok || stack.status == cloudformation.StackStatusReviewInProgress {
+			return nil, newFakeStackDoesNotExistError(name)
+		}
+
+		switch stack.status {
+		case cloudformation.StackStatusRollbackComplete, cloudformation.StackStatusUpdateRollbackFailed:
+			return nil, awserr.New(
+				"ValidationError",
+				fmt.Sprintf("Stack:%s is in %s state and can not be updated.", stack.id, stack.status),
+				nil,
+			)
+		}
+	}
+
+	changeSet := &fakeChangeSet{
+		id:   client.newARN("changeSet", aws.StringValue(input.ChangeSetName)),
+		name: aws.StringValue(input.ChangeSetName),
+
+		changeSetType:   aws.StringValue(input.ChangeSetType),
+		executionStatus: cloudformation.ExecutionStatusAvailable,
+		status:          cloudformation.ChangeSetStatusCreateComplete,
+
+		capabilities:          input.Capabilities,
+		notificationARNs:      toFakeNotificationARNs(stack, input.NotificationARNs),
+		parameters:            withFakeParameterDefaults(parsed, input.Parameters),
+		rollbackConfiguration: toFakeRollbackConfiguration(stack, input.RollbackConfiguration),
+		tags:                  input.Tags,
+		template:              template,
+
+		creationTime: time.Now(),
+	}
+
+	var oldResources map[string]fakeTemplateResource
+
+	if stack.status != cloudformation.StackStatusReviewInProgress {
+		oldTemplate, err := parseFakeTemplate(stack.template)
+		if err != nil {
+			return nil, err
+		}
+
+		oldResources = oldTemplate.Resources
+
+		if stack.template == changeSet.template &&
+			reflect.DeepEqual(stack.notificationARNs, changeSet.notificationARNs) &&
+			reflect.DeepEqual(stack.parameters, changeSet.parameters) &&
+			reflect.DeepEqual(stack.rollbackConfiguration, changeSet.rollbackConfiguration) &&
+			reflect.DeepEqual(stack.tags, changeSet.tags) {
+			changeSet.executionStatus = cloudformation.ExecutionStatusUnavailable
+			changeSet.status = cloudformation.ChangeSetStatusFailed
+			changeSet.statusReason = aws.String(noopChangeSetStatusReason)
+		}
+	}
+
+	changeSet.changes = diffFakeResources(oldResources, parsed.Resources)
+
+	stack.changeSets = append(stack.changeSets, changeSet)
+
+	output := &cloudformation.CreateChangeSetOutput{
+		Id:      aws.String(changeSet.id),
+		StackId: aws.String(stack.id),
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) DeleteStackWithContext(
+	_ aws.Context,
+	input *cloudformation.DeleteStackInput,
+	_ ...request.Option,
+) (*cloudformation.DeleteStackOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	name := aws.StringValue(input.StackName)
+
+	stack, ok := client.stacks[name]
+	if !ok {
+		return new(cloudformation.DeleteStackOutput), nil
+	}
+
+	if stack.terminationProtection {
+		return nil, awserr.New(
+			"ValidationError",
+			fmt.Sprintf(
+				"Stack [%s] cannot be deleted while TerminationProtection is enabled",
+				name,
+			),
+			nil,
+		)
+	}
+
+	// deleted stacks are no longer found by name, so their deletion events are
+	// not recorded
+	delete(client.stacks, name)
+
+	return new(cloudformation.DeleteStackOutput), nil
+}
+
+func (client *CloudFormationFake) DescribeChangeSetWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeChangeSetInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeChangeSetOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, changeSet, err := client.findChangeSet(input.StackName, input.ChangeSetName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &cloudformation.DescribeChangeSetOutput{
+		Capabilities:          changeSet.capabilities,
+		ChangeSetId:           aws.String(changeSet.id),
+		ChangeSetName:         aws.String(changeSet.name),
+		Changes:               changeSet.changes,
+		CreationTime:          aws.Time(changeSet.creationTime),
+		ExecutionStatus:       aws.String(changeSet.executionStatus),
+		NotificationARNs:      changeSet.notificationARNs,
+		Parameters:            changeSet.parameters,
+		RollbackConfiguration: changeSet.rollbackConfiguration,
+		StackId:               aws.String(stack.id),
+		StackName:             aws.String(stack.name),
+		Status:                aws.String(changeSet.status),
+		StatusReason:          changeSet.statusReason,
+		Tags:                  changeSet.tags,
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) DescribeStackDriftDetectionStatusWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStackDriftDetectionStatusInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	output, ok := client.driftDetections[aws.StringValue(input.StackDriftDetectionId)]
+	if !ok {
+		return nil, awserr.New(
+			"ValidationError",
+			fmt.Sprintf(
+				"Drift detection [%s] does not exist",
+				aws.StringValue(input.StackDriftDetectionId),
+			),
+			nil,
+		)
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) DescribeStackEventsWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStackEventsInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeStackEventsOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	// most recent first, like the real API
+	events := make([]*cloudformation.StackEvent, len(stack.events))
+	for index, event := range stack.events {
+		events[len(stack.events)-1-index] = event
+	}
+
+	output := &cloudformation.DescribeStackEventsOutput{
+		StackEvents: events,
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) DescribeStackResourceDriftsWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStackResourceDriftsInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeStackResourceDriftsOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := aws.StringValueSlice(input.StackResourceDriftStatusFilters)
+
+	drifts := make([]*cloudformation.StackResourceDrift, 0)
+
+	for _, drift := range stack.resourceDrifts {
+		if len(filters) == 0 || containsString(filters, aws.StringValue(drift.StackResourceDriftStatus)) {
+			drifts = append(drifts, drift)
+		}
+	}
+
+	output := &cloudformation.DescribeStackResourceDriftsOutput{
+		StackResourceDrifts: drifts,
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) DescribeStacksWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStacksInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeStacksOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*cloudformation.Output, 0)
+
+	template, err := parseFakeTemplate(stack.template)
+	if err == nil {
+		keys := make([]string, 0, len(template.Outputs))
+		for key := range template.Outputs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, ok := template.Outputs[key].Value.(string)
+			if !ok {
+				value = fmt.Sprintf("fake-%s", key)
+			}
+
+			output := &cloudformation.Output{
+				OutputKey:   aws.String(key),
+				OutputValue: aws.String(value),
+			}
+
+			outputs = append(outputs, output)
+		}
+	}
+
+	description := &cloudformation.Stack{
+		Capabilities:                stack.capabilities,
+		ChangeSetId:                 stack.changeSetID,
+		CreationTime:                aws.Time(stack.creationTime),
+		DisableRollback:             aws.Bool(stack.disableRollback),
+		EnableTerminationProtection: aws.Bool(stack.terminationProtection),
+		LastUpdatedTime:             stack.lastUpdatedTime,
+		NotificationARNs:            stack.notificationARNs,
+		Outputs:                     outputs,
+		Parameters:                  stack.parameters,
+		RollbackConfiguration:       stack.rollbackConfiguration,
+		StackId:                     aws.String(stack.id),
+		StackName:                   aws.String(stack.name),
+		StackStatus:                 aws.String(stack.status),
+		Tags:                        stack.tags,
+	}
+
+	output := &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{description},
+	}
+
+	return output, nil
+}
+
+// DetectStackDriftWithContext completes detection synchronously against the
+// drifts set through SetResourceDrifts.
+func (client *CloudFormationFake) DetectStackDriftWithContext(
+	_ aws.Context,
+	input *cloudformation.DetectStackDriftInput,
+	_ ...request.Option,
+) (*cloudformation.DetectStackDriftOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if stack.status == cloudformation.StackStatusReviewInProgress {
+		return nil, newFakeStackDoesNotExistError(stack.name)
+	}
+
+	client.counter++
+	id := fmt.Sprintf("%08d-0000-4000-8000-000000000000", client.counter)
+
+	driftStatus := cloudformation.StackDriftStatusInSync
+	if len(stack.resourceDrifts) != 0 {
+		driftStatus = cloudformation.StackDriftStatusDrifted
+	}
+
+	client.driftDetections[id] = &cloudformation.DescribeStackDriftDetectionStatusOutput{
+		DetectionStatus:           aws.String(cloudformation.StackDriftDetectionStatusDetectionComplete),
+		DriftedStackResourceCount: aws.Int64(int64(len(stack.resourceDrifts))),
+		StackDriftDetectionId:     aws.String(id),
+		StackDriftStatus:          aws.String(driftStatus),
+		StackId:                   aws.String(stack.id),
+		Timestamp:                 aws.Time(time.Now()),
+	}
+
+	output := &cloudformation.DetectStackDriftOutput{
+		StackDriftDetectionId: aws.String(id),
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) ExecuteChangeSetWithContext(
+	_ aws.Context,
+	input *cloudformation.ExecuteChangeSetInput,
+	_ ...request.Option,
+) (*cloudformation.ExecuteChangeSetOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, changeSet, err := client.findChangeSet(input.StackName, input.ChangeSetName)
+	if err != nil {
+		return nil, err
+	}
+
+	if changeSet.executionStatus != cloudformation.ExecutionStatusAvailable {
+		return nil, awserr.New(
+			cloudformation.ErrCodeInvalidChangeSetStatusException,
+			fmt.Sprintf(
+				"ChangeSet [%s] cannot be executed in its current execution status of [%s]",
+				changeSet.id,
+				changeSet.executionStatus,
+			),
+			nil,
+		)
+	}
+
+	inProgress := cloudformation.StackStatusUpdateInProgress
+	complete := cloudformation.StackStatusUpdateComplete
+
+	if changeSet.changeSetType == cloudformation.ChangeSetTypeCreate {
+		inProgress = cloudformation.StackStatusCreateInProgress
+		complete = cloudformation.StackStatusCreateComplete
+	}
+
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", inProgress)
+
+	for _, change := range changeSet.changes {
+		resourceChange := change.ResourceChange
+
+		statuses := map[string][]string{
+			cloudformation.ChangeActionAdd: {
+				cloudformation.ResourceStatusCreateInProgress,
+				cloudformation.ResourceStatusCreateComplete,
+			},
+			cloudformation.ChangeActionModify: {
+				cloudformation.ResourceStatusUpdateInProgress,
+				cloudformation.ResourceStatusUpdateComplete,
+			},
+			cloudformation.ChangeActionRemove: {
+				cloudformation.ResourceStatusDeleteInProgress,
+				cloudformation.ResourceStatusDeleteComplete,
+			},
+		}[aws.StringValue(resourceChange.Action)]
+
+		for _, status := range statuses {
+			client.addEvent(
+				stack,
+				aws.StringValue(resourceChange.LogicalResourceId),
+				aws.StringValue(resourceChange.ResourceType),
+				status,
+			)
+		}
+	}
+
+	client.addEvent(stack, stack.name, "AWS::CloudFormation::Stack", complete)
+
+	stack.capabilities = changeSet.capabilities
+	stack.changeSetID = aws.String(changeSet.id)
+	stack.disableRollback = aws.BoolValue(input.DisableRollback)
+	stack.notificationARNs = changeSet.notificationARNs
+	stack.parameters = changeSet.parameters
+	stack.rollbackConfiguration = changeSet.rollbackConfiguration
+	stack.status = complete
+	stack.tags = changeSet.tags
+	stack.template = changeSet.template
+
+	if changeSet.changeSetType != cloudformation.ChangeSetTypeCreate {
+		stack.lastUpdatedTime = aws.Time(time.Now())
+	}
+
+	// executing a change set deletes every change set on the stack
+	stack.changeSets = make([]*fakeChangeSet, 0)
+
+	return new(cloudformation.ExecuteChangeSetOutput), nil
+}
+
+func (client *CloudFormationFake) GetStackPolicyWithContext(
+	_ aws.Context,
+	input *cloudformation.GetStackPolicyInput,
+	_ ...request.Option,
+) (*cloudformation.GetStackPolicyOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &cloudformation.GetStackPolicyOutput{
+		StackPolicyBody: stack.policy,
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) GetTemplateWithContext(
+	_ aws.Context,
+	input *cloudformation.GetTemplateInput,
+	_ ...request.Option,
+) (*cloudformation.GetTemplateOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	if input.ChangeSetName != nil {
+		_, changeSet, err := client.findChangeSet(input.StackName, input.ChangeSetName)
+		if err != nil {
+			return nil, err
+		}
+
+		output := &cloudformation.GetTemplateOutput{
+			StagesAvailable: aws.StringSlice([]string{cloudformation.TemplateStageOriginal}),
+			TemplateBody:    aws.String(changeSet.template),
+		}
+
+		return output, nil
+	}
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &cloudformation.GetTemplateOutput{
+		StagesAvailable: aws.StringSlice([]string{cloudformation.TemplateStageOriginal}),
+		TemplateBody:    aws.String(stack.template),
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) ListChangeSetsWithContext(
+	_ aws.Context,
+	input *cloudformation.ListChangeSetsInput,
+	_ ...request.Option,
+) (*cloudformation.ListChangeSetsOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*cloudformation.ChangeSetSummary, len(stack.changeSets))
+
+	for index, changeSet := range stack.changeSets {
+		summaries[index] = &cloudformation.ChangeSetSummary{
+			ChangeSetId:     aws.String(changeSet.id),
+			ChangeSetName:   aws.String(changeSet.name),
+			CreationTime:    aws.Time(changeSet.creationTime),
+			ExecutionStatus: aws.String(changeSet.executionStatus),
+			StackId:         aws.String(stack.id),
+			StackName:       aws.String(stack.name),
+			Status:          aws.String(changeSet.status),
+			StatusReason:    changeSet.statusReason,
+		}
+	}
+
+	output := &cloudformation.ListChangeSetsOutput{
+		Summaries: summaries,
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) SetStackPolicyWithContext(
+	_ aws.Context,
+	input *cloudformation.SetStackPolicyInput,
+	_ ...request.Option,
+) (*cloudformation.SetStackPolicyOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := client.readObject(input.StackPolicyBody, input.StackPolicyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	stack.policy = aws.String(policy)
+
+	return new(cloudformation.SetStackPolicyOutput), nil
+}
+
+func (client *CloudFormationFake) UpdateTerminationProtectionWithContext(
+	_ aws.Context,
+	input *cloudformation.UpdateTerminationProtectionInput,
+	_ ...request.Option,
+) (*cloudformation.UpdateTerminationProtectionOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	stack, err := client.findStack(input.StackName)
+	if err != nil {
+		return nil, err
+	}
+
+	stack.terminationProtection = aws.BoolValue(input.EnableTerminationProtection)
+
+	output := &cloudformation.UpdateTerminationProtectionOutput{
+		StackId: aws.String(stack.id),
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) WaitUntilChangeSetCreateCompleteWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeChangeSetInput,
+	options ...request.WaiterOption,
+) error {
+	client.Lock()
+	_, changeSet, err := client.findChangeSet(input.StackName, input.ChangeSetName)
+	if err != nil {
+		client.Unlock()
+		return err
+	}
+	status := changeSet.status
+	client.Unlock()
+
+	runFakeWaiterOptions(options)
+
+	if status != cloudformation.ChangeSetStatusCreateComplete {
+		return newFakeResourceNotReadyError()
+	}
+
+	return nil
+}
+
+func (client *CloudFormationFake) WaitUntilStackCreateCompleteWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStacksInput,
+	options ...request.WaiterOption,
+) error {
+	return client.waitUntilStackStatus(
+		input.StackName,
+		cloudformation.StackStatusCreateComplete,
+		options,
+	)
+}
+
+func (client *CloudFormationFake) WaitUntilStackDeleteCompleteWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStacksInput,
+	options ...request.WaiterOption,
+) error {
+	client.Lock()
+	_, err := client.findStack(input.StackName)
+	client.Unlock()
+
+	runFakeWaiterOptions(options)
+
+	if IsStackDoesNotExistError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return newFakeResourceNotReadyError()
+}
+
+func (client *CloudFormationFake) WaitUntilStackUpdateCompleteWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStacksInput,
+	options ...request.WaiterOption,
+) error {
+	return client.waitUntilStackStatus(
+		input.StackName,
+		cloudformation.StackStatusUpdateComplete,
+		options,
+	)
+}
+
+func (client *CloudFormationFake) ValidateTemplateWithContext(
+	_ aws.Context,
+	input *cloudformation.ValidateTemplateInput,
+	_ ...request.Option,
+) (*cloudformation.ValidateTemplateOutput, error) {
+	client.Lock()
+	defer client.Unlock()
+
+	template, err := client.readObject(input.TemplateBody, input.TemplateURL)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseFakeTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(parsed.Parameters))
+	for key := range parsed.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parameters := make([]*cloudformation.TemplateParameter, len(keys))
+
+	for index, key := range keys {
+		parameter := parsed.Parameters[key]
+
+		parameters[index] = &cloudformation.TemplateParameter{
+			DefaultValue: toFakeString(parameter.Default),
+			Description:  aws.String(parameter.Description),
+			NoEcho:       aws.Bool(toFakeBool(parameter.NoEcho)),
+			ParameterKey: aws.String(key),
+		}
+	}
+
+	output := &cloudformation.ValidateTemplateOutput{
+		Capabilities: aws.StringSlice(requiredFakeCapabilities(parsed)),
+		Parameters:   parameters,
+	}
+
+	if len(output.Capabilities) != 0 {
+		output.CapabilitiesReason = aws.String(
+			"The following resource(s) require capabilities: " +
+				strings.Join(requiredFakeCapabilityResources(parsed), ", "),
+		)
+	}
+
+	return output, nil
+}
+
+func (client *CloudFormationFake) addEvent(
+	stack *fakeStack,
+	logicalID string,
+	resourceType string,
+	status string,
+) {
+	client.counter++
+
+	event := &cloudformation.StackEvent{
+		EventId:           aws.String(fmt.Sprintf("fake-event-%d", client.counter)),
+		LogicalResourceId: aws.String(logicalID),
+		ResourceStatus:    aws.String(status),
+		ResourceType:      aws.String(resourceType),
+		StackId:           aws.String(stack.id),
+		StackName:         aws.String(stack.name),
+		Timestamp:         aws.Time(time.Now()),
+	}
+
+	stack.events = append(stack.events, event)
+}
+
+func (client *CloudFormationFake) findChangeSet(
+	stackName *string,
+	changeSetName *string,
+) (*fakeStack, *fakeChangeSet, error) {
+	stack, err := client.findStack(stackName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, changeSet := range stack.changeSets {
+		if changeSet.name == aws.StringValue(changeSetName) ||
+			changeSet.id == aws.StringValue(changeSetName) {
+			return stack, changeSet, nil
+		}
+	}
+
+	return nil, nil, awserr.New(
+		cloudformation.ErrCodeChangeSetNotFoundException,
+		fmt.Sprintf("ChangeSet [%s] does not exist", aws.StringValue(changeSetName)),
+		nil,
+	)
+}
+
+func (client *CloudFormationFake) findStack(name *string) (*fakeStack, error) {
+	stack, ok := client.stacks[aws.StringValue(name)]
+	if !ok {
+		return nil, newFakeStackDoesNotExistError(aws.StringValue(name))
+	}
+
+	return stack, nil
+}
+
+func (client *CloudFormationFake) newARN(resource, name string) string {
+	client.counter++
+
+	return fmt.Sprintf(
+		"arn:aws:cloudformation:%s:%s:%s/%s/%08d-0000-4000-8000-000000000000",
+		fakeRegion,
+		fakeAccountID,
+		resource,
+		name,
+		client.counter,
+	)
+}
+
+func (client *CloudFormationFake) newStack(name string) *fakeStack {
+	stack := &fakeStack{
+		id:     client.newARN("stack", name),
+		name:   name,
+		status: cloudformation.StackStatusReviewInProgress,
+
+		changeSets: make([]*fakeChangeSet, 0),
+		events:     make([]*cloudformation.StackEvent, 0),
+
+		creationTime: time.Now(),
+	}
+
+	client.addEvent(stack, name, "AWS::CloudFormation::Stack", cloudformation.StackStatusReviewInProgress)
+
+	return stack
+}
+
+func (client *CloudFormationFake) readObject(body, url *string) (string, error) {
+	if body != nil {
+		return *body, nil
+	}
+
+	if url == nil {
+		return "", awserr.New("ValidationError", "either a body or a URL must be specified", nil)
+	}
+
+	if client.s3 == nil {
+		return "", awserr.New("ValidationError", "S3 is not available to the fake", nil)
+	}
+
+	data, ok := client.s3.ObjectFromURL(*url)
+	if !ok {
+		return "", awserr.New(
+			"ValidationError",
+			fmt.Sprintf("S3 object '%s' does not exist", *url),
+			nil,
+		)
+	}
+
+	return string(data), nil
+}
+
+func (client *CloudFormationFake) waitUntilStackStatus(
+	name *string,
+	status string,
+	options []request.WaiterOption,
+) error {
+	client.Lock()
+	stack, err := client.findStack(name)
+	if err != nil {
+		client.Unlock()
+		return err
+	}
+	actual := stack.status
+	client.Unlock()
+
+	runFakeWaiterOptions(options)
+
+	if actual != status {
+		return newFakeResourceNotReadyError()
+	}
+
+	return nil
+}
+
+func checkFakeCapabilities(template *fakeTemplate, actual []*string) error {
+	for _, capability := range requiredFakeCapabilities(template) {
+		if !matchesFakeCapability(capability, toStringList(actual)) {
+			return awserr.New(
+				cloudformation.ErrCodeInsufficientCapabilitiesException,
+				fmt.Sprintf("Requires capabilities : [%s]", capability),
+				nil,
+			)
+		}
+	}
+
+	return nil
+}
+
+func diffFakeResources(
+	oldResources map[string]fakeTemplateResource,
+	newResources map[string]fakeTemplateResource,
+) []*cloudformation.Change {
+	changes := make([]*cloudformation.Change, 0)
+
+	add := func(action, logicalID, resourceType string) {
+		change := &cloudformation.Change{
+			ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String(action),
+				Details:           make([]*cloudformation.ResourceChangeDetail, 0),
+				LogicalResourceId: aws.String(logicalID),
+				ResourceType:      aws.String(resourceType),
+				Scope:             make([]*string, 0),
+			},
+			Type: aws.String(cloudformation.ChangeTypeResource),
+		}
+
+		if action == cloudformation.ChangeActionModify {
+			change.ResourceChange.Replacement = aws.String(cloudformation.ReplacementFalse)
+			change.ResourceChange.Scope = aws.StringSlice([]string{cloudformation.ResourceAttributeProperties})
+		}
+
+		changes = append(changes, change)
+	}
+
+	for _, logicalID := range sortedFakeResourceIDs(newResources) {
+		newResource := newResources[logicalID]
+
+		oldResource, ok := oldResources[logicalID]
+
+		switch {
+		case !ok:
+			add(cloudformation.ChangeActionAdd, logicalID, newResource.Type)
+
+		case !reflect.DeepEqual(oldResource, newResource):
+			add(cloudformation.ChangeActionModify, logicalID, newResource.Type)
+		}
+	}
+
+	for _, logicalID := range sortedFakeResourceIDs(oldResources) {
+		if _, ok := newResources[logicalID]; !ok {
+			add(cloudformation.ChangeActionRemove, logicalID, oldResources[logicalID].Type)
+		}
+	}
+
+	return changes
+}
+
+func matchesFakeCapability(required string, actual []string) bool {
+	for _, capability := range actual {
+		if capability == required {
+			return true
+		}
+
+		// named IAM resources are a superset of IAM resources
+		if required == cloudformation.CapabilityCapabilityIam &&
+			capability == cloudformation.CapabilityCapabilityNamedIam {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newFakeResourceNotReadyError() error {
+	return awserr.New(
+		request.WaiterResourceNotReadyErrorCode,
+		"failed waiting for successful resource state",
+		nil,
+	)
+}
+
+func newFakeStackDoesNotExistError(name string) error {
+	return awserr.New(
+		"ValidationError",
+		fmt.Sprintf("Stack with id %s does not exist", name),
+		nil,
+	)
+}
+
+func parseFakeTemplate(body string) (*fakeTemplate, error) {
+	template := new(fakeTemplate)
+
+	var document interface{}
+
+	err := yaml.Unmarshal([]byte(body), &document)
+	if err != nil {
+		return nil, awserr.New("ValidationError", fmt.Sprintf("Template format error: %s", err), nil)
+	}
+
+	// tolerate scalar templates, which are common in tests
+	if _, ok := document.(map[interface{}]interface{}); !ok {
+		return template, nil
+	}
+
+	err = yaml.Unmarshal([]byte(body), template)
+	if err != nil {
+		return nil, awserr.New("ValidationError", fmt.Sprintf("Template format error: %s", err), nil)
+	}
+
+	return template, nil
+}
+
+func requiredFakeCapabilities(template *fakeTemplate) []string {
+	capabilities := make([]string, 0)
+
+	hasIAM, hasNamedIAM := false, false
+
+	for _, resource := range template.Resources {
+		if !strings.HasPrefix(resource.Type, "AWS::IAM::") {
+			continue
+		}
+
+		hasIAM = true
+
+		for property := range resource.Properties {
+			if strings.HasSuffix(property, "Name") {
+				hasNamedIAM = true
+			}
+		}
+	}
+
+	switch {
+	case hasNamedIAM:
+		capabilities = append(capabilities, cloudformation.CapabilityCapabilityNamedIam)
+	case hasIAM:
+		capabilities = append(capabilities, cloudformation.CapabilityCapabilityIam)
+	}
+
+	if template.Transform != nil {
+		capabilities = append(capabilities, cloudformation.CapabilityCapabilityAutoExpand)
+	}
+
+	return capabilities
+}
+
+func requiredFakeCapabilityResources(template *fakeTemplate) []string {
+	slice := make([]string, 0)
+
+	for _, logicalID := range sortedFakeResourceIDs(template.Resources) {
+		if strings.HasPrefix(template.Resources[logicalID].Type, "AWS::IAM::") {
+			slice = append(slice, fmt.Sprintf("[%s]", logicalID))
+		}
+	}
+
+	return slice
+}
+
+func runFakeWaiterOptions(options []request.WaiterOption) {
+	waiter := new(request.Waiter)
+	waiter.ApplyOptions(options...)
+
+	for _, option := range waiter.RequestOptions {
+		option(new(request.Request))
+	}
+}
+
+func sortedFakeResourceIDs(resources map[string]fakeTemplateResource) []string {
+	keys := make([]string, 0, len(resources))
+
+	for key := range resources {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func toFakeBool(value interface{}) bool {
+	switch typed := value.(type) {
+	case bool:
+		return typed
+	case string:
+		return strings.EqualFold(typed, "true")
+	default:
+		return false
+	}
+}
+
+func toFakeString(value interface{}) *string {
+	if value == nil {
+		return nil
+	}
+
+	return aws.String(fmt.Sprintf("%v", value))
+}
+
+func withFakeParameterDefaults(
+	template *fakeTemplate,
+	parameters []*cloudformation.Parameter,
+) []*cloudformation.Parameter {
+	slice := make([]*cloudformation.Parameter, 0, len(template.Parameters))
+
+	provided := make(map[string]bool, len(parameters))
+
+	for _, parameter := range parameters {
+		provided[aws.StringValue(parameter.ParameterKey)] = true
+
+		slice = append(slice, &cloudformation.Parameter{
+			ParameterKey:   parameter.ParameterKey,
+			ParameterValue: parameter.ParameterValue,
+		})
+	}
+
+	keys := make([]string, 0, len(template.Parameters))
+	for key := range template.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		defaultValue := toFakeString(template.Parameters[key].Default)
+
+		if provided[key] || defaultValue == nil {
+			continue
+		}
+
+		slice = append(slice, &cloudformation.Parameter{
+			ParameterKey:   aws.String(key),
+			ParameterValue: defaultValue,
+		})
+	}
+
+	sort.Slice(slice, func(i, j int) bool {
+		return aws.StringValue(slice[i].ParameterKey) < aws.StringValue(slice[j].ParameterKey)
+	})
+
+	return slice
+}
+
+// toFakeNotificationARNs keeps the stack's previous ARNs if none are given, and
+// clears them if an empty list is given, like CloudFormation.
+func toFakeNotificationARNs(stack *fakeStack, arns []*string) []*string {
+	if arns == nil {
+		return stack.notificationARNs
+	}
+
+	if len(arns) == 0 {
+		return nil
+	}
+
+	return arns
+}
+
+// toFakeRollbackConfiguration keeps the stack's previous configuration if none
+// is given, and clears it if an empty one is given, like CloudFormation.
+func toFakeRollbackConfiguration(
+	stack *fakeStack,
+	rollbackConfiguration *cloudformation.RollbackConfiguration,
+) *cloudformation.RollbackConfiguration {
+	if rollbackConfiguration == nil {
+		return stack.rollbackConfiguration
+	}
+
+	if len(rollbackConfiguration.RollbackTriggers) == 0 &&
+		aws.Int64Value(rollbackConfiguration.MonitoringTimeInMinutes) == 0 {
+		return nil
+	}
+
+	return rollbackConfiguration
+}
@@ -15,6 +15,30 @@ func NewCloudFormationMock() *CloudFormationMock {
 	return new(CloudFormationMock)
 }
 
+func (client *CloudFormationMock) CancelUpdateStackWithContext(
+	_ aws.Context,
+	input *cloudformation.CancelUpdateStackInput,
+	_ ...request.Option,
+) (*cloudformation.CancelUpdateStackOutput, error) {
+	args := client.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudformation.CancelUpdateStackOutput), args.Error(1)
+}
+
+func (client *CloudFormationMock) ContinueUpdateRollbackWithContext(
+	_ aws.Context,
+	input *cloudformation.ContinueUpdateRollbackInput,
+	_ ...request.Option,
+) (*cloudformation.ContinueUpdateRollbackOutput, error) {
+	args := client.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudformation.ContinueUpdateRollbackOutput), args.Error(1)
+}
+
 func (client *CloudFormationMock) CreateChangeSetWithContext(
 	_ aws.Context,
 	input *cloudformation.CreateChangeSetInput,
@@ -63,6 +87,18 @@ func (client *CloudFormationMock) DescribeStacksWithContext(
 	return args.Get(0).(*cloudformation.DescribeStacksOutput), args.Error(1)
 }
 
+func (client *CloudFormationMock) DescribeStackDriftDetectionStatusWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStackDriftDetectionStatusInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	args := client.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudformation.DescribeStackDriftDetectionStatusOutput), args.Error(1)
+}
+
 func (client *CloudFormationMock) DescribeStackEventsWithContext(
 	_ aws.Context,
 	input *cloudformation.DescribeStackEventsInput,
@@ -75,6 +111,30 @@ func (client *CloudFormationMock) DescribeStackEventsWithContext(
 	return args.Get(0).(*cloudformation.DescribeStackEventsOutput), args.Error(1)
 }
 
+func (client *CloudFormationMock) DescribeStackResourceDriftsWithContext(
+	_ aws.Context,
+	input *cloudformation.DescribeStackResourceDriftsInput,
+	_ ...request.Option,
+) (*cloudformation.DescribeStackResourceDriftsOutput, error) {
+	args := client.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudformation.DescribeStackResourceDriftsOutput), args.Error(1)
+}
+
+func (client *CloudFormationMock) DetectStackDriftWithContext(
+	_ aws.Context,
+	input *cloudformation.DetectStackDriftInput,
+	_ ...request.Option,
+) (*cloudformation.DetectStackDriftOutput, error) {
+	args := client.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*cloudformation.DetectStackDriftOutput), args.Error(1)
+}
+
 func (client *CloudFormationMock) ExecuteChangeSetWithContext(
 	_ aws.Context,
 	input *cloudformation.ExecuteChangeSetInput,
@@ -0,0 +1,139 @@
+package stratus
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	OutputsFormatDotenv = "dotenv"
+	OutputsFormatGitHub = "github"
+	OutputsFormatJSON   = "json"
+	OutputsFormatShell  = "shell"
+
+	gitHubDelimiter = "STRATUS_EOF"
+)
+
+var (
+	OutputsFormats = []string{
+		OutputsFormatDotenv,
+		OutputsFormatGitHub,
+		OutputsFormatJSON,
+		OutputsFormatShell,
+	}
+
+	envNameInvalidRegexp = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+// StackOutputs holds output values by key, for each stack by name.
+type StackOutputs map[string]map[string]string
+
+// FormatOutputs renders stack outputs for downstream tooling. JSON nests
+// outputs under their stack name, while the line-based formats prefix each
+// output key with its stack name, e.g. MY_STACK_BUCKETNAME.
+func FormatOutputs(format string, outputs StackOutputs) ([]byte, error) {
+	switch format {
+	case OutputsFormatJSON:
+		data, err := json.MarshalIndent(outputs, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(data, '\n'), nil
+
+	case OutputsFormatDotenv:
+		return formatOutputLines(outputs, func(name, value string) string {
+			return fmt.Sprintf("%s=%s", name, quoteDotenv(value))
+		}), nil
+
+	case OutputsFormatShell:
+		return formatOutputLines(outputs, func(name, value string) string {
+			return fmt.Sprintf("export %s=%s", name, quoteShell(value))
+		}), nil
+
+	case OutputsFormatGitHub:
+		return formatOutputLines(outputs, formatGitHubOutput), nil
+
+	default:
+		return nil, fmt.Errorf(
+			"outputs format '%s' is not one of %s",
+			format,
+			strings.Join(OutputsFormats, ", "),
+		)
+	}
+}
+
+func formatOutputLines(
+	outputs StackOutputs,
+	formatLine func(name, value string) string,
+) []byte {
+	builder := new(strings.Builder)
+
+	for _, stackName := range sortedKeys(outputs) {
+		values := outputs[stackName]
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			builder.WriteString(formatLine(toEnvName(stackName, key), values[key]))
+			builder.WriteString("\n")
+		}
+	}
+
+	return []byte(builder.String())
+}
+
+// formatGitHubOutput writes multiline values with a heredoc delimiter that
+// doesn't appear in the value.
+func formatGitHubOutput(name, value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		return fmt.Sprintf("%s=%s", name, value)
+	}
+
+	delimiter := gitHubDelimiter
+	for index := 1; strings.Contains(value, delimiter); index++ {
+		delimiter = fmt.Sprintf("%s_%d", gitHubDelimiter, index)
+	}
+
+	return fmt.Sprintf("%s<<%s\n%s\n%s", name, delimiter, value, delimiter)
+}
+
+func quoteDotenv(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+
+	return fmt.Sprintf(`"%s"`, replacer.Replace(value))
+}
+
+func quoteShell(value string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(value, "'", `'\''`))
+}
+
+func toEnvName(stackName, key string) string {
+	name := strings.ToUpper(fmt.Sprintf("%s_%s", stackName, key))
+
+	return envNameInvalidRegexp.ReplaceAllString(name, "_")
+}
+
+func sortedKeys(outputs StackOutputs) []string {
+	keys := make([]string, 0, len(outputs))
+
+	for key := range outputs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
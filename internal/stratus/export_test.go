@@ -0,0 +1,92 @@
+package stratus_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func Test_FormatOutputs(t *testing.T) {
+	outputs := stratus.StackOutputs{
+		"stratus-app": {
+			"Url": "https://example.com/?a='1'",
+		},
+		"stratus-network": {
+			"Note":  "line 1\nline \"2\"",
+			"VpcId": "vpc-123",
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		format        string
+		expected      string
+		expectedError string
+	}{
+		{
+			description: "json",
+			format:      stratus.OutputsFormatJSON,
+			expected: `{
+  "stratus-app": {
+    "Url": "https://example.com/?a='1'"
+  },
+  "stratus-network": {
+    "Note": "line 1\nline \"2\"",
+    "VpcId": "vpc-123"
+  }
+}
+`,
+		},
+		{
+			description: "dotenv",
+			format:      stratus.OutputsFormatDotenv,
+			expected: `STRATUS_APP_URL="https://example.com/?a='1'"
+STRATUS_NETWORK_NOTE="line 1\nline \"2\""
+STRATUS_NETWORK_VPCID="vpc-123"
+`,
+		},
+		{
+			description: "shell",
+			format:      stratus.OutputsFormatShell,
+			expected: `export STRATUS_APP_URL='https://example.com/?a='\''1'\'''
+export STRATUS_NETWORK_NOTE='line 1
+line "2"'
+export STRATUS_NETWORK_VPCID='vpc-123'
+`,
+		},
+		{
+			description: "github",
+			format:      stratus.OutputsFormatGitHub,
+			expected: `STRATUS_APP_URL=https://example.com/?a='1'
+STRATUS_NETWORK_NOTE<<STRATUS_EOF
+line 1
+line "2"
+STRATUS_EOF
+STRATUS_NETWORK_VPCID=vpc-123
+`,
+		},
+		{
+			description:   "unrecognised",
+			format:        "xml",
+			expectedError: "outputs format 'xml' is not one of dotenv, github, json, shell",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			data, err := stratus.FormatOutputs(testCase.format, outputs)
+
+			if testCase.expectedError != "" {
+				assert.EqualError(err, testCase.expectedError)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(testCase.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package stratus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+
+	"github.com/72636c/stratus/internal/config"
+)
+
+type ProtectionViolation struct {
+	Action       string
+	LogicalID    string
+	ResourceType string
+}
+
+type ProtectionViolations []*ProtectionViolation
+
+func (violations ProtectionViolations) LogType() string {
+	return "protectionViolations"
+}
+
+func (violations ProtectionViolations) Summary() string {
+	lines := make([]string, len(violations))
+
+	for index, violation := range violations {
+		lines[index] = fmt.Sprintf(
+			"%-*s %-*s %s",
+			len(config.ProtectionActionReplace),
+			violation.Action,
+			maxStackResourceTypeLength,
+			violation.ResourceType,
+			violation.LogicalID,
+		)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// FindProtectionViolations lists the changes in a change set that a stack's
+// protections do not allow and that have not been acknowledged.
+func FindProtectionViolations(
+	stack *config.Stack,
+	changeSet *cloudformation.DescribeChangeSetOutput,
+) ProtectionViolations {
+	violations := make(ProtectionViolations, 0)
+
+	if changeSet == nil || len(stack.Protect) == 0 {
+		return violations
+	}
+
+	for _, change := range changeSet.Changes {
+		resourceChange := change.ResourceChange
+		if resourceChange == nil {
+			continue
+		}
+
+		logicalID := aws.StringValue(resourceChange.LogicalResourceId)
+		resourceType := aws.StringValue(resourceChange.ResourceType)
+
+		if containsString(stack.AcknowledgeDestructive, logicalID) {
+			continue
+		}
+
+		action := toProtectionAction(resourceChange)
+
+		for _, protection := range stack.Protect {
+			if protection.Matches(logicalID, resourceType) && !protection.Allows(action) {
+				violation := &ProtectionViolation{
+					Action:       action,
+					LogicalID:    logicalID,
+					ResourceType: resourceType,
+				}
+
+				violations = append(violations, violation)
+				break
+			}
+		}
+	}
+
+	return violations
+}
+
+// toProtectionAction treats a conditional replacement as a replacement, as it
+// cannot be ruled out before execution.
+func toProtectionAction(resourceChange *cloudformation.ResourceChange) string {
+	action := aws.StringValue(resourceChange.Action)
+
+	if action != cloudformation.ChangeActionModify {
+		return action
+	}
+
+	switch aws.StringValue(resourceChange.Replacement) {
+	case cloudformation.ReplacementTrue, cloudformation.ReplacementConditional:
+		return config.ProtectionActionReplace
+	default:
+		return action
+	}
+}
@@ -0,0 +1,391 @@
+package stratus
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/logrusorgru/aurora"
+)
+
+const (
+	noReplacement = "-"
+)
+
+var (
+	changeTableHeader = []string{
+		"ACTION",
+		"LOGICAL ID",
+		"TYPE",
+		"REPLACEMENT",
+		"SCOPE",
+		"CAUSED BY",
+	}
+
+	stackSummaryTableHeader = []string{
+		"STACK",
+		"STATUS",
+		"LAST UPDATED",
+		"TERMINATION PROTECTION",
+		"PENDING CHANGE SET",
+		"CHECKSUM",
+	}
+
+	// summary counts are listed in this order, skipping actions with no changes
+	changeActions = []string{
+		cloudformation.ChangeActionAdd,
+		cloudformation.ChangeActionModify,
+		cloudformation.ChangeActionRemove,
+		cloudformation.ChangeActionImport,
+		cloudformation.ChangeActionDynamic,
+	}
+)
+
+type colourFunc func(arg interface{}) aurora.Value
+
+type changeRow struct {
+	action      string
+	replacement string
+	cells       []string
+}
+
+// renderDiff formats a diff as a table of resource changes, followed by a
+// summary line and any stack-level changes.
+func renderDiff(diff *Diff, colour bool) string {
+	builder := new(strings.Builder)
+
+	var changes []*cloudformation.Change
+	if diff.ChangeSet != nil {
+		changes = diff.ChangeSet.Changes
+	}
+
+	rows := toChangeRows(changes)
+
+	if len(rows) != 0 {
+		writeChangeTable(builder, rows, colour)
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(summariseChanges(rows))
+
+	for _, line := range diffStackState(diff.Old, diff.New) {
+		builder.WriteString("\n")
+		builder.WriteString(line)
+	}
+
+	return builder.String()
+}
+
+func toChangeRows(changes []*cloudformation.Change) []*changeRow {
+	rows := make([]*changeRow, 0, len(changes))
+
+	for _, change := range changes {
+		resourceChange := change.ResourceChange
+		if resourceChange == nil {
+			continue
+		}
+
+		action := aws.StringValue(resourceChange.Action)
+
+		replacement := noReplacement
+		if action == cloudformation.ChangeActionModify && resourceChange.Replacement != nil {
+			replacement = *resourceChange.Replacement
+		}
+
+		row := &changeRow{
+			action:      action,
+			replacement: replacement,
+			cells: []string{
+				action,
+				aws.StringValue(resourceChange.LogicalResourceId),
+				aws.StringValue(resourceChange.ResourceType),
+				replacement,
+				strings.Join(aws.StringValueSlice(resourceChange.Scope), ", "),
+				strings.Join(toCausingProperties(resourceChange.Details), ", "),
+			},
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// toCausingProperties lists the properties that trigger a change, marking
+// those that force replacement with an asterisk.
+func toCausingProperties(details []*cloudformation.ResourceChangeDetail) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, detail := range details {
+		target := detail.Target
+		if target == nil || target.Name == nil {
+			continue
+		}
+
+		name := *target.Name
+		if aws.StringValue(target.RequiresRecreation) == cloudformation.RequiresRecreationAlways {
+			name += "*"
+		}
+
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func writeChangeTable(builder *strings.Builder, rows []*changeRow, colour bool) {
+	cells := make([][]string, len(rows))
+	for index, row := range rows {
+		cells[index] = row.cells
+	}
+
+	widths := toColumnWidths(changeTableHeader, cells)
+
+	writeChangeTableRow(builder, changeTableHeader, widths, func(index int) colourFunc {
+		if colour {
+			return aurora.Bold
+		}
+
+		return nil
+	})
+
+	for _, row := range rows {
+		writeChangeTableRow(builder, row.cells, widths, func(index int) colourFunc {
+			if !colour {
+				return nil
+			}
+
+			switch index {
+			case 0:
+				return toActionColour(row.action)
+			case 3:
+				return toReplacementColour(row.replacement)
+			default:
+				return nil
+			}
+		})
+	}
+}
+
+func toColumnWidths(header []string, rows [][]string) []int {
+	widths := make([]int, len(header))
+
+	for index, cell := range header {
+		widths[index] = len(cell)
+	}
+
+	for _, row := range rows {
+		for index, cell := range row {
+			if len(cell) > widths[index] {
+				widths[index] = len(cell)
+			}
+		}
+	}
+
+	return widths
+}
+
+func writeChangeTableRow(
+	builder *strings.Builder,
+	cells []string,
+	widths []int,
+	toColour func(index int) colourFunc,
+) {
+	padded := make([]string, len(cells))
+
+	for index, cell := range cells {
+		text := cell
+
+		// pad before colouring, as escape codes have no width
+		if index != len(cells)-1 {
+			text = fmt.Sprintf("%-*s", widths[index], cell)
+		}
+
+		if colourise := toColour(index); colourise != nil {
+			text = colourise(text).String()
+		}
+
+		padded[index] = text
+	}
+
+	builder.WriteString(strings.TrimRight(strings.Join(padded, "  "), " "))
+	builder.WriteString("\n")
+}
+
+// renderStackSummaries formats stack summaries as a table with a row per
+// stack.
+func renderStackSummaries(summaries StackSummaries) string {
+	builder := new(strings.Builder)
+
+	rows := make([][]string, len(summaries))
+
+	for index, summary := range summaries {
+		rows[index] = toStackSummaryCells(summary)
+	}
+
+	widths := toColumnWidths(stackSummaryTableHeader, rows)
+
+	noColour := func(int) colourFunc { return nil }
+
+	writeChangeTableRow(builder, stackSummaryTableHeader, widths, noColour)
+
+	for _, row := range rows {
+		writeChangeTableRow(builder, row, widths, noColour)
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func toStackSummaryCells(summary *StackSummary) []string {
+	if !summary.Exists {
+		return []string{summary.Name, "DOES_NOT_EXIST", "-", "-", "-", "-"}
+	}
+
+	lastUpdated := "-"
+	if summary.LastUpdatedTime != nil {
+		lastUpdated = summary.LastUpdatedTime.UTC().Format(time.RFC3339)
+	}
+
+	checksum := "differs"
+	switch {
+	case summary.ChecksumUnknown:
+		checksum = "unknown"
+	case summary.ChecksumMatches:
+		checksum = "matches"
+	}
+
+	return []string{
+		summary.Name,
+		summary.StackStatus,
+		lastUpdated,
+		fmt.Sprintf("%t", summary.TerminationProtection),
+		fmt.Sprintf("%t", summary.PendingChangeSet),
+		checksum,
+	}
+}
+
+func summariseChanges(rows []*changeRow) string {
+	if len(rows) == 0 {
+		return "No resource changes."
+	}
+
+	counts := make(map[string]int)
+	replacements := make(map[string]int)
+
+	for _, row := range rows {
+		counts[row.action]++
+		replacements[row.replacement]++
+	}
+
+	parts := make([]string, 0, len(changeActions))
+
+	for _, action := range changeActions {
+		count, ok := counts[action]
+		if !ok {
+			continue
+		}
+
+		part := fmt.Sprintf("%d %s", count, strings.ToLower(action))
+
+		if action == cloudformation.ChangeActionModify {
+			part += summariseReplacements(replacements)
+		}
+
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func summariseReplacements(replacements map[string]int) string {
+	parts := make([]string, 0, 2)
+
+	if count := replacements[cloudformation.ReplacementTrue]; count != 0 {
+		parts = append(parts, fmt.Sprintf("%d replace", count))
+	}
+
+	if count := replacements[cloudformation.ReplacementConditional]; count != 0 {
+		parts = append(parts, fmt.Sprintf("%d conditional replace", count))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%s)", strings.Join(parts, ", "))
+}
+
+// diffStackState describes changes to stack-level settings that a change set
+// does not list.
+func diffStackState(oldState, newState *StackState) []string {
+	lines := make([]string, 0)
+
+	if oldState == nil || newState == nil {
+		return lines
+	}
+
+	if !reflect.DeepEqual(oldState.StackPolicy, newState.StackPolicy) {
+		lines = append(lines, "Stack policy will be updated.")
+	}
+
+	if aws.BoolValue(oldState.TerminationProtection) != aws.BoolValue(newState.TerminationProtection) {
+		lines = append(lines, fmt.Sprintf(
+			"Termination protection will change from %t to %t.",
+			aws.BoolValue(oldState.TerminationProtection),
+			aws.BoolValue(newState.TerminationProtection),
+		))
+	}
+
+	if aws.BoolValue(oldState.DisableRollback) != aws.BoolValue(newState.DisableRollback) {
+		lines = append(lines, fmt.Sprintf(
+			"Disable rollback will change from %t to %t.",
+			aws.BoolValue(oldState.DisableRollback),
+			aws.BoolValue(newState.DisableRollback),
+		))
+	}
+
+	if !matchesStringSet(aws.StringValueSlice(oldState.NotificationARNs), aws.StringValueSlice(newState.NotificationARNs)) {
+		lines = append(lines, "Notification ARNs will be updated.")
+	}
+
+	if !matchesRollbackConfiguration(oldState.RollbackConfiguration, newState.RollbackConfiguration) {
+		lines = append(lines, "Rollback configuration will be updated.")
+	}
+
+	return lines
+}
+
+func toActionColour(action string) colourFunc {
+	switch action {
+	case cloudformation.ChangeActionAdd:
+		return aurora.Green
+	case cloudformation.ChangeActionModify:
+		return aurora.Yellow
+	case cloudformation.ChangeActionRemove:
+		return aurora.Red
+	default:
+		return aurora.Cyan
+	}
+}
+
+func toReplacementColour(replacement string) colourFunc {
+	switch replacement {
+	case cloudformation.ReplacementTrue:
+		return func(arg interface{}) aurora.Value {
+			return aurora.Bold(aurora.Red(arg))
+		}
+	case cloudformation.ReplacementConditional:
+		return aurora.Yellow
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,200 @@
+package stratus_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/72636c/stratus/internal/stratus"
+)
+
+func newResourceChange(
+	action string,
+	id string,
+	replacement string,
+	details ...*cloudformation.ResourceChangeDetail,
+) *cloudformation.Change {
+	change := &cloudformation.ResourceChange{
+		Action:            aws.String(action),
+		Details:           details,
+		LogicalResourceId: aws.String(id),
+		ResourceType:      aws.String("AWS::S3::Bucket"),
+	}
+
+	if replacement != "" {
+		change.Replacement = aws.String(replacement)
+		change.Scope = aws.StringSlice([]string{cloudformation.ResourceAttributeProperties})
+	}
+
+	return &cloudformation.Change{
+		ResourceChange: change,
+		Type:           aws.String(cloudformation.ChangeTypeResource),
+	}
+}
+
+func newPropertyDetail(name, requiresRecreation string) *cloudformation.ResourceChangeDetail {
+	return &cloudformation.ResourceChangeDetail{
+		Target: &cloudformation.ResourceTargetDefinition{
+			Attribute:          aws.String(cloudformation.ResourceAttributeProperties),
+			Name:               aws.String(name),
+			RequiresRecreation: aws.String(requiresRecreation),
+		},
+	}
+}
+
+func Test_Diff_Summary(t *testing.T) {
+	testCases := []struct {
+		description string
+		diff        *stratus.Diff
+		expected    string
+	}{
+		{
+			description: "no change set",
+			diff:        &stratus.Diff{},
+			expected:    "No resource changes.",
+		},
+		{
+			description: "resource changes",
+			diff: &stratus.Diff{
+				ChangeSet: &cloudformation.DescribeChangeSetOutput{
+					Changes: []*cloudformation.Change{
+						newResourceChange(cloudformation.ChangeActionAdd, "New", ""),
+						newResourceChange(
+							cloudformation.ChangeActionModify,
+							"Replaced",
+							cloudformation.ReplacementTrue,
+							newPropertyDetail("BucketName", cloudformation.RequiresRecreationAlways),
+							newPropertyDetail("Tags", cloudformation.RequiresRecreationNever),
+						),
+						newResourceChange(
+							cloudformation.ChangeActionModify,
+							"Updated",
+							cloudformation.ReplacementFalse,
+							newPropertyDetail("Tags", cloudformation.RequiresRecreationNever),
+						),
+						newResourceChange(cloudformation.ChangeActionRemove, "Old", ""),
+					},
+				},
+			},
+			expected: "" +
+				"ACTION  LOGICAL ID  TYPE             REPLACEMENT  SCOPE       CAUSED BY\n" +
+				"Add     New         AWS::S3::Bucket  -\n" +
+				"Modify  Replaced    AWS::S3::Bucket  True         Properties  BucketName*, Tags\n" +
+				"Modify  Updated     AWS::S3::Bucket  False        Properties  Tags\n" +
+				"Remove  Old         AWS::S3::Bucket  -\n" +
+				"\n" +
+				"1 add, 2 modify (1 replace), 1 remove",
+		},
+		{
+			description: "stack-level changes",
+			diff: &stratus.Diff{
+				New: &stratus.StackState{
+					StackPolicy:           map[string]interface{}{"Statement": []interface{}{}},
+					TerminationProtection: aws.Bool(true),
+				},
+				Old: &stratus.StackState{
+					TerminationProtection: aws.Bool(false),
+				},
+			},
+			expected: "" +
+				"No resource changes.\n" +
+				"Stack policy will be updated.\n" +
+				"Termination protection will change from false to true.",
+		},
+		{
+			description: "rollback and notification changes",
+			diff: &stratus.Diff{
+				New: &stratus.StackState{
+					DisableRollback:  aws.Bool(true),
+					NotificationARNs: aws.StringSlice([]string{"arn:aws:sns:ap-southeast-2:000000000000:a"}),
+					RollbackConfiguration: &cloudformation.RollbackConfiguration{
+						MonitoringTimeInMinutes: aws.Int64(10),
+					},
+				},
+				Old: &stratus.StackState{
+					DisableRollback: aws.Bool(false),
+				},
+			},
+			expected: "" +
+				"No resource changes.\n" +
+				"Disable rollback will change from false to true.\n" +
+				"Notification ARNs will be updated.\n" +
+				"Rollback configuration will be updated.",
+		},
+		{
+			description: "rollback and notifications in another order",
+			diff: &stratus.Diff{
+				New: &stratus.StackState{
+					DisableRollback: aws.Bool(false),
+					NotificationARNs: aws.StringSlice([]string{
+						"arn:aws:sns:ap-southeast-2:000000000000:a",
+						"arn:aws:sns:ap-southeast-2:000000000000:b",
+					}),
+					RollbackConfiguration: &cloudformation.RollbackConfiguration{
+						RollbackTriggers: []*cloudformation.RollbackTrigger{
+							{Arn: aws.String("arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:a")},
+							{Arn: aws.String("arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:b")},
+						},
+					},
+				},
+				Old: &stratus.StackState{
+					NotificationARNs: aws.StringSlice([]string{
+						"arn:aws:sns:ap-southeast-2:000000000000:b",
+						"arn:aws:sns:ap-southeast-2:000000000000:a",
+					}),
+					RollbackConfiguration: &cloudformation.RollbackConfiguration{
+						MonitoringTimeInMinutes: aws.Int64(0),
+						RollbackTriggers: []*cloudformation.RollbackTrigger{
+							{Arn: aws.String("arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:b")},
+							{Arn: aws.String("arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:a")},
+						},
+					},
+				},
+			},
+			expected: "No resource changes.",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			assert.Equal(testCase.expected, testCase.diff.Summary())
+		})
+	}
+}
+
+func Test_StackSummaries_Summary(t *testing.T) {
+	assert := assert.New(t)
+
+	summaries := stratus.StackSummaries{
+		{
+			Name:                  "network",
+			Exists:                true,
+			StackStatus:           cloudformation.StackStatusUpdateComplete,
+			LastUpdatedTime:       aws.Time(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)),
+			TerminationProtection: true,
+			ChecksumMatches:       true,
+		},
+		{
+			Name:            "database",
+			Exists:          true,
+			StackStatus:     cloudformation.StackStatusCreateComplete,
+			ChecksumUnknown: true,
+		},
+		{
+			Name: "app",
+		},
+	}
+
+	expected := "" +
+		"STACK     STATUS           LAST UPDATED          TERMINATION PROTECTION  PENDING CHANGE SET  CHECKSUM\n" +
+		"network   UPDATE_COMPLETE  2020-01-02T03:04:05Z  true                    false               matches\n" +
+		"database  CREATE_COMPLETE  -                     false                   false               unknown\n" +
+		"app       DOES_NOT_EXIST   -                     -                       -                   -"
+
+	assert.Equal(expected, summaries.Summary())
+}
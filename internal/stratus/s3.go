@@ -8,6 +8,7 @@ import (
 
 var (
 	_ S3 = new(s3.S3)
+	_ S3 = new(S3Fake)
 	_ S3 = new(S3Mock)
 )
 
@@ -0,0 +1,72 @@
+package stratus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Fake is an in-memory stand-in for S3 that stores object bodies by bucket
+// and key.
+type S3Fake struct {
+	sync.RWMutex
+	objects map[string][]byte
+}
+
+func NewS3Fake() *S3Fake {
+	return &S3Fake{
+		RWMutex: sync.RWMutex{},
+		objects: make(map[string][]byte),
+	}
+}
+
+func (client *S3Fake) PutObjectWithContext(
+	_ aws.Context,
+	input *s3.PutObjectInput,
+	_ ...request.Option,
+) (*s3.PutObjectOutput, error) {
+	if input.Bucket == nil || input.Key == nil || input.Body == nil {
+		return nil, awserr.New("InvalidRequest", "bucket, key and body are required", nil)
+	}
+
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Lock()
+	client.objects[toS3URL(*input.Bucket, *input.Key)] = data
+	client.Unlock()
+
+	return new(s3.PutObjectOutput), nil
+}
+
+func (client *S3Fake) Object(bucket, key string) ([]byte, bool) {
+	return client.ObjectFromURL(toS3URL(bucket, key))
+}
+
+func (client *S3Fake) ObjectFromURL(url string) ([]byte, bool) {
+	client.RLock()
+	data, ok := client.objects[url]
+	client.RUnlock()
+
+	return data, ok
+}
+
+func (client *S3Fake) String() string {
+	client.RLock()
+	defer client.RUnlock()
+
+	keys := make([]string, 0, len(client.objects))
+	for key := range client.objects {
+		keys = append(keys, key)
+	}
+
+	return fmt.Sprintf("S3Fake{%s}", strings.Join(keys, ", "))
+}
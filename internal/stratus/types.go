@@ -1,7 +1,9 @@
 package stratus
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awsutil"
@@ -44,27 +46,95 @@ type Diff struct {
 	Old       *StackState
 }
 
+func (diff *Diff) ColourSummary() string {
+	return renderDiff(diff, true)
+}
+
 func (diff *Diff) HasChangeSet() bool {
 	return diff.ChangeSet != nil
 }
 
+func (diff *Diff) LogType() string {
+	return "diff"
+}
+
+func (diff *Diff) Summary() string {
+	return renderDiff(diff, false)
+}
+
 func (diff *Diff) String() string {
 	return awsutil.Prettify(diff)
 }
 
+type Drift struct {
+	StackDriftStatus string
+	Resources        []*cloudformation.StackResourceDrift
+}
+
+func (drift *Drift) HasDrift() bool {
+	return drift.StackDriftStatus == cloudformation.StackDriftStatusDrifted
+}
+
+func (drift *Drift) LogType() string {
+	return "drift"
+}
+
+func (drift *Drift) Summary() string {
+	if len(drift.Resources) == 0 {
+		return fmt.Sprintf("Stack drift status is %s.", drift.StackDriftStatus)
+	}
+
+	lines := make([]string, len(drift.Resources))
+
+	for index, resource := range drift.Resources {
+		lines[index] = formatResourceDrift(resource)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type Outputs []*cloudformation.Output
+
+func (outputs Outputs) LogType() string {
+	return "outputs"
+}
+
+// Map returns output values by key.
+func (outputs Outputs) Map() map[string]string {
+	values := make(map[string]string, len(outputs))
+
+	for _, output := range outputs {
+		values[aws.StringValue(output.OutputKey)] = aws.StringValue(output.OutputValue)
+	}
+
+	return values
+}
+
+type StackEvent struct {
+	*cloudformation.StackEvent
+}
+
+func (event *StackEvent) LogType() string {
+	return "stackEvent"
+}
+
+func (event *StackEvent) Summary() string {
+	return formatStackEvent(event.StackEvent)
+}
+
 type StackEventCache struct {
-	ids []string
+	ids map[string]struct{}
 }
 
 func NewStackEventCache(events []*cloudformation.StackEvent) *StackEventCache {
-	slice := make([]string, len(events))
+	ids := make(map[string]struct{}, len(events))
 
-	for index, event := range events {
-		slice[index] = *event.EventId
+	for _, event := range events {
+		ids[*event.EventId] = struct{}{}
 	}
 
 	return &StackEventCache{
-		ids: slice,
+		ids: ids,
 	}
 }
 
@@ -73,13 +143,8 @@ func (cache *StackEventCache) Contains(event *cloudformation.StackEvent) bool {
 		return false
 	}
 
-	for _, id := range cache.ids {
-		if id == *event.EventId {
-			return true
-		}
-	}
-
-	return false
+	_, ok := cache.ids[*event.EventId]
+	return ok
 }
 
 func (cache *StackEventCache) Diff(
@@ -89,7 +154,7 @@ func (cache *StackEventCache) Diff(
 
 	for _, event := range events {
 		if !cache.Contains(event) {
-			cache.ids = append(cache.ids, *event.EventId)
+			cache.ids[*event.EventId] = struct{}{}
 			slice = append(slice, event)
 		}
 	}
@@ -97,7 +162,40 @@ func (cache *StackEventCache) Diff(
 	return slice
 }
 
+// StackSummary describes where a deployed stack stands relative to its config.
+type StackSummary struct {
+	Name   string
+	Exists bool
+
+	StackStatus           string     `json:",omitempty"`
+	LastUpdatedTime       *time.Time `json:",omitempty"`
+	TerminationProtection bool
+
+	// PendingChangeSet is set when a change set matching the config is staged
+	PendingChangeSet bool
+
+	// ChecksumMatches is set when the last executed change set matches the config
+	ChecksumMatches bool
+
+	// ChecksumUnknown is set when the config references an upstream stack or
+	// output that doesn't exist yet, so its checksum can't be compared
+	ChecksumUnknown bool `json:",omitempty"`
+}
+
+type StackSummaries []*StackSummary
+
+func (summaries StackSummaries) LogType() string {
+	return "status"
+}
+
+func (summaries StackSummaries) Summary() string {
+	return renderStackSummaries(summaries)
+}
+
 type StackState struct {
+	DisableRollback       *bool
+	NotificationARNs      []*string
+	RollbackConfiguration *cloudformation.RollbackConfiguration
 	StackPolicy           interface{}
 	TerminationProtection *bool
 }
@@ -2,6 +2,7 @@ package stratus
 
 import (
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"sort"
@@ -22,27 +23,44 @@ const (
 )
 
 var (
+	assetExtensionToContentType = map[string]string{
+		".jar": "application/java-archive",
+		".zip": "application/zip",
+	}
+
 	changeSetRegexp = regexp.MustCompile(`stratus-(create|update)-[0-9a-f]{64}`)
 
+	changeSetChecksumRegexp = regexp.MustCompile(`stratus-(?:create|update)-([0-9a-f]{64})`)
+
 	extensionToContentType = map[string]string{
 		".json": "application/json; charset=utf-8",
 		".yaml": "application/x-yaml; charset=utf-8",
 		".yml":  "application/x-yaml; charset=utf-8",
 	}
 
+	// statuses that block change sets until Client.Recover resolves them
+	recoverableStatuses = []string{
+		cloudformation.StackStatusRollbackComplete,
+		cloudformation.StackStatusUpdateRollbackFailed,
+	}
+
 	// best guesses
 	maxStackStatusLength       = len(cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress)
 	maxStackResourceTypeLength = len("AWS::KinesisAnalyticsV2::ApplicationCloudWatchLoggingOption")
 )
 
+// MatchesChangeSetContents skips capabilities in auto mode, as they follow
+// from the template and are only adopted when staging.
 func MatchesChangeSetContents(
 	stack *config.Stack,
 	changeSet *cloudformation.DescribeChangeSetOutput,
 	template *cloudformation.GetTemplateOutput,
 ) bool {
 	return string(stack.Template) == *template.TemplateBody &&
-		matchesChangeSetCapabilities(stack.Capabilities, changeSet.Capabilities) &&
-		matchesChangeSetParameters(stack.Parameters, changeSet.Parameters)
+		(stack.AutoCapabilities || matchesChangeSetCapabilities(stack.Capabilities, changeSet.Capabilities)) &&
+		matchesChangeSetNotificationARNs(stack.NotificationARNs, changeSet.NotificationARNs) &&
+		matchesChangeSetParameters(stack.Parameters, changeSet.Parameters) &&
+		matchesChangeSetRollbackConfiguration(stack.RollbackTriggers, changeSet.RollbackConfiguration)
 }
 
 func MatchesChangeSetSummary(
@@ -55,6 +73,17 @@ func MatchesChangeSetSummary(
 		matchesChangeSetName(stack.Checksum, *summary.ChangeSetName)
 }
 
+// getChangeSetChecksum extracts the config checksum from a change set name or
+// ID, or returns an empty string if the change set wasn't created by Stratus.
+func getChangeSetChecksum(str string) string {
+	raw := changeSetChecksumRegexp.FindStringSubmatch(str)
+	if len(raw) != 2 {
+		return ""
+	}
+
+	return raw[1]
+}
+
 func getChangeSetType(name string) (ChangeSetType, error) {
 	raw := changeSetRegexp.FindStringSubmatch(name)
 	if len(raw) != 2 {
@@ -69,6 +98,16 @@ func getChangeSetType(name string) (ChangeSetType, error) {
 	return changeSetType, nil
 }
 
+func containsString(slice []string, str string) bool {
+	for _, item := range slice {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
+
 func formatStackEvent(event *cloudformation.StackEvent) string {
 	builder := new(strings.Builder)
 
@@ -90,6 +129,33 @@ func formatStackEvent(event *cloudformation.StackEvent) string {
 	return builder.String()
 }
 
+func formatResourceDrift(drift *cloudformation.StackResourceDrift) string {
+	builder := new(strings.Builder)
+
+	summary := fmt.Sprintf(
+		"%-*s %-*s %s",
+		maxStackStatusLength,
+		aws.StringValue(drift.StackResourceDriftStatus),
+		maxStackResourceTypeLength,
+		aws.StringValue(drift.ResourceType),
+		aws.StringValue(drift.LogicalResourceId),
+	)
+	builder.WriteString(summary)
+
+	for _, difference := range drift.PropertyDifferences {
+		details := fmt.Sprintf(
+			"\n└ %s %s: expected %s, actual %s",
+			aws.StringValue(difference.DifferenceType),
+			aws.StringValue(difference.PropertyPath),
+			aws.StringValue(difference.ExpectedValue),
+			aws.StringValue(difference.ActualValue),
+		)
+		builder.WriteString(details)
+	}
+
+	return builder.String()
+}
+
 func isAcceptableChangeSetStatus(
 	summary *cloudformation.ChangeSetSummary,
 ) bool {
@@ -137,7 +203,9 @@ func isResourceNotReadyError(err error) bool {
 	return awsError.Code() == request.WaiterResourceNotReadyErrorCode
 }
 
-func isStackDoesNotExistError(err error) bool {
+// IsStackDoesNotExistError reports whether err is CloudFormation's response to
+// a stack that doesn't exist.
+func IsStackDoesNotExistError(err error) bool {
 	if err == nil {
 		return false
 	}
@@ -151,6 +219,37 @@ func isStackDoesNotExistError(err error) bool {
 		strings.Contains(awsError.Message(), "does not exist")
 }
 
+// RecoverableStatusError is returned when a change set is rejected because the
+// stack is stuck in a status that Client.Recover can resolve.
+type RecoverableStatusError struct {
+	StackName string
+	Status    string
+}
+
+func (err *RecoverableStatusError) Error() string {
+	return fmt.Sprintf(
+		"stack '%s' is in %s and cannot take a change set; run recover or pass --auto-recover",
+		err.StackName,
+		err.Status,
+	)
+}
+
+func isValidationError(err error) bool {
+	awsError, ok := err.(awserr.Error)
+
+	return ok && awsError.Code() == "ValidationError"
+}
+
+func isRecoverableStatus(status string) bool {
+	for _, candidate := range recoverableStatuses {
+		if status == candidate {
+			return true
+		}
+	}
+
+	return false
+}
+
 func matchesChangeSetCapabilities(expected []string, actual []*string) bool {
 	return reflect.DeepEqual(
 		sort.StringSlice(expected),
@@ -158,6 +257,53 @@ func matchesChangeSetCapabilities(expected []string, actual []*string) bool {
 	)
 }
 
+func matchesChangeSetNotificationARNs(expected []string, actual []*string) bool {
+	return matchesStringSet(expected, toStringList(actual))
+}
+
+func matchesChangeSetRollbackConfiguration(
+	expected *config.StackRollbackTriggers,
+	actual *cloudformation.RollbackConfiguration,
+) bool {
+	expectedAlarms := make([]string, 0)
+	expectedMinutes := int64(0)
+
+	if expected != nil {
+		expectedAlarms = expected.AlarmARNs
+		expectedMinutes = expected.MonitoringMinutes
+	}
+
+	actualAlarms, actualMinutes := fromRollbackConfiguration(actual)
+
+	return expectedMinutes == actualMinutes &&
+		matchesStringSet(expectedAlarms, actualAlarms)
+}
+
+func matchesRollbackConfiguration(
+	expected *cloudformation.RollbackConfiguration,
+	actual *cloudformation.RollbackConfiguration,
+) bool {
+	expectedAlarms, expectedMinutes := fromRollbackConfiguration(expected)
+	actualAlarms, actualMinutes := fromRollbackConfiguration(actual)
+
+	return expectedMinutes == actualMinutes &&
+		matchesStringSet(expectedAlarms, actualAlarms)
+}
+
+func matchesStringSet(expected, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	sortedExpected := append(make([]string, 0, len(expected)), expected...)
+	sortedActual := append(make([]string, 0, len(actual)), actual...)
+
+	sort.Strings(sortedExpected)
+	sort.Strings(sortedActual)
+
+	return reflect.DeepEqual(sortedExpected, sortedActual)
+}
+
 func matchesChangeSetName(checksum, changeSetName string) bool {
 	str := fmt.Sprintf("stratus-(create|update)-%s", regexp.QuoteMeta(checksum))
 
@@ -250,6 +396,78 @@ func toContentDisposition(filename string) string {
 	return fmt.Sprintf(`attachment; filename=%s`, strconv.Quote(filename))
 }
 
+// toNotificationARNs is never nil, as CloudFormation keeps the previous ARNs on
+// an update that omits them.
+func toNotificationARNs(arns []string) []*string {
+	return aws.StringSlice(append(make([]string, 0, len(arns)), arns...))
+}
+
+func fromRollbackConfiguration(
+	rollbackConfiguration *cloudformation.RollbackConfiguration,
+) ([]string, int64) {
+	alarms := make([]string, 0)
+
+	if rollbackConfiguration == nil {
+		return alarms, 0
+	}
+
+	for _, trigger := range rollbackConfiguration.RollbackTriggers {
+		if trigger != nil && trigger.Arn != nil {
+			alarms = append(alarms, *trigger.Arn)
+		}
+	}
+
+	return alarms, aws.Int64Value(rollbackConfiguration.MonitoringTimeInMinutes)
+}
+
+// toRollbackConfiguration is never nil, as CloudFormation keeps the previous
+// configuration on an update that omits it.
+func toRollbackConfiguration(
+	triggers *config.StackRollbackTriggers,
+) *cloudformation.RollbackConfiguration {
+	if triggers == nil {
+		return &cloudformation.RollbackConfiguration{
+			RollbackTriggers: make([]*cloudformation.RollbackTrigger, 0),
+		}
+	}
+
+	slice := make([]*cloudformation.RollbackTrigger, len(triggers.AlarmARNs))
+
+	for index, arn := range triggers.AlarmARNs {
+		slice[index] = &cloudformation.RollbackTrigger{
+			Arn:  aws.String(arn),
+			Type: aws.String("AWS::CloudWatch::Alarm"),
+		}
+	}
+
+	return &cloudformation.RollbackConfiguration{
+		MonitoringTimeInMinutes: aws.Int64(triggers.MonitoringMinutes),
+		RollbackTriggers:        slice,
+	}
+}
+
+func toRoleARN(roleARN string) *string {
+	if roleARN == "" {
+		return nil
+	}
+
+	return aws.String(roleARN)
+}
+
+func toAssetContentType(key string) string {
+	extension := strings.ToLower(filepath.Ext(key))
+
+	if contentType, ok := extensionToContentType[extension]; ok {
+		return contentType
+	}
+
+	if contentType, ok := assetExtensionToContentType[extension]; ok {
+		return contentType
+	}
+
+	return "application/octet-stream"
+}
+
 func toS3URL(bucket, key string) string {
 	return fmt.Sprintf("https://s3.amazonaws.com/%s/%s", bucket, key)
 }
@@ -184,6 +184,29 @@ func Test_MatchesChangeSetContents(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			description: "change set has unexpected notification ARNs",
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				Capabilities: make([]*string, 0),
+				NotificationARNs: []*string{
+					stringPointer("arn:aws:sns:ap-southeast-2:000000000000:topic"),
+				},
+				Parameters: []*cloudformation.Parameter{
+					{
+						ParameterKey:   stringPointer("2"),
+						ParameterValue: stringPointer("b"),
+					},
+					{
+						ParameterKey:   stringPointer("1"),
+						ParameterValue: stringPointer("a"),
+					},
+				},
+			},
+			template: &cloudformation.GetTemplateOutput{
+				TemplateBody: stringPointer("{}"),
+			},
+			expected: false,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -200,3 +223,145 @@ func Test_MatchesChangeSetContents(t *testing.T) {
 		})
 	}
 }
+
+func Test_MatchesChangeSetContents_StackSettings(t *testing.T) {
+	const (
+		alarmA = "arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:a"
+		alarmB = "arn:aws:cloudwatch:ap-southeast-2:000000000000:alarm:b"
+		topicA = "arn:aws:sns:ap-southeast-2:000000000000:a"
+		topicB = "arn:aws:sns:ap-southeast-2:000000000000:b"
+	)
+
+	newTriggers := func(minutes int64, alarms ...string) *cloudformation.RollbackConfiguration {
+		triggers := make([]*cloudformation.RollbackTrigger, len(alarms))
+
+		for index, alarm := range alarms {
+			triggers[index] = &cloudformation.RollbackTrigger{
+				Arn:  aws.String(alarm),
+				Type: aws.String("AWS::CloudWatch::Alarm"),
+			}
+		}
+
+		return &cloudformation.RollbackConfiguration{
+			MonitoringTimeInMinutes: aws.Int64(minutes),
+			RollbackTriggers:        triggers,
+		}
+	}
+
+	withRollback := &config.Stack{
+		Capabilities:     make([]string, 0),
+		NotificationARNs: []string{topicA, topicB},
+		RollbackTriggers: &config.StackRollbackTriggers{
+			AlarmARNs:         []string{alarmA, alarmB},
+			MonitoringMinutes: 10,
+		},
+		Template: []byte("{}"),
+	}
+
+	withoutRollback := &config.Stack{
+		Capabilities:    make([]string, 0),
+		DisableRollback: true,
+		Template:        []byte("{}"),
+	}
+
+	testCases := []struct {
+		description string
+
+		stack     *config.Stack
+		changeSet *cloudformation.DescribeChangeSetOutput
+
+		expected bool
+	}{
+		{
+			description: "notification ARNs and rollback triggers in another order",
+			stack:       withRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				NotificationARNs:      aws.StringSlice([]string{topicB, topicA}),
+				RollbackConfiguration: newTriggers(10, alarmB, alarmA),
+			},
+			expected: true,
+		},
+		{
+			description: "missing notification ARN",
+			stack:       withRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				NotificationARNs:      aws.StringSlice([]string{topicA}),
+				RollbackConfiguration: newTriggers(10, alarmA, alarmB),
+			},
+			expected: false,
+		},
+		{
+			description: "different monitoring time",
+			stack:       withRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				NotificationARNs:      aws.StringSlice([]string{topicA, topicB}),
+				RollbackConfiguration: newTriggers(5, alarmA, alarmB),
+			},
+			expected: false,
+		},
+		{
+			description: "different rollback alarm",
+			stack:       withRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				NotificationARNs:      aws.StringSlice([]string{topicA, topicB}),
+				RollbackConfiguration: newTriggers(10, alarmA),
+			},
+			expected: false,
+		},
+		{
+			description: "no rollback configuration",
+			stack:       withRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				NotificationARNs: aws.StringSlice([]string{topicA, topicB}),
+			},
+			expected: false,
+		},
+		{
+			description: "empty rollback configuration matches no triggers",
+			stack:       withoutRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				RollbackConfiguration: &cloudformation.RollbackConfiguration{},
+			},
+			expected: true,
+		},
+		{
+			description: "empty notification ARNs match no ARNs",
+			stack:       withoutRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				NotificationARNs: make([]*string, 0),
+			},
+			expected: true,
+		},
+		{
+			// rollback is disabled on execution, so it doesn't affect the change set
+			description: "disable rollback",
+			stack:       withoutRollback,
+			changeSet:   &cloudformation.DescribeChangeSetOutput{},
+			expected:    true,
+		},
+		{
+			description: "unexpected rollback triggers",
+			stack:       withoutRollback,
+			changeSet: &cloudformation.DescribeChangeSetOutput{
+				RollbackConfiguration: newTriggers(0, alarmA),
+			},
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			actual := stratus.MatchesChangeSetContents(
+				testCase.stack,
+				testCase.changeSet,
+				&cloudformation.GetTemplateOutput{
+					TemplateBody: stringPointer("{}"),
+				},
+			)
+
+			assert.Equal(testCase.expected, actual)
+		})
+	}
+}
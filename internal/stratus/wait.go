@@ -0,0 +1,162 @@
+package stratus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+
+	"github.com/72636c/stratus/internal/config"
+	"github.com/72636c/stratus/internal/context"
+)
+
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultWaitTimeout  = 2 * time.Hour
+	initialPollDelay    = 1 * time.Second
+)
+
+// TimeoutError is returned when a stack operation is still in progress after
+// its timeout expires.
+type TimeoutError struct {
+	StackName string
+	Status    string
+	Timeout   time.Duration
+}
+
+func (err *TimeoutError) Error() string {
+	if err.Status == "" {
+		return fmt.Sprintf("stack '%s' timed out after %s", err.StackName, err.Timeout)
+	}
+
+	return fmt.Sprintf(
+		"stack '%s' timed out after %s with status %s",
+		err.StackName,
+		err.Timeout,
+		err.Status,
+	)
+}
+
+type waitSettings struct {
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+// newWaitSettings resolves polling and timeout settings, preferring CLI
+// overrides to the stack's config.
+func newWaitSettings(ctx context.Context, stack *config.Stack) *waitSettings {
+	settings := &waitSettings{
+		pollInterval: defaultPollInterval,
+		timeout:      defaultWaitTimeout,
+	}
+
+	if stack.PollInterval != 0 {
+		settings.pollInterval = stack.PollInterval
+	}
+
+	if stack.Timeout != 0 {
+		settings.timeout = stack.Timeout
+	}
+
+	if interval := context.PollInterval(ctx); interval != 0 {
+		settings.pollInterval = interval
+	}
+
+	if timeout := context.WaitTimeout(ctx); timeout != 0 {
+		settings.timeout = timeout
+	}
+
+	return settings
+}
+
+// delay backs off exponentially from the initial delay, capped at the poll
+// interval.
+func (settings *waitSettings) delay(attempt int) time.Duration {
+	delay := initialPollDelay
+
+	for index := 1; index < attempt && delay < settings.pollInterval; index++ {
+		delay *= 2
+	}
+
+	if delay > settings.pollInterval {
+		return settings.pollInterval
+	}
+
+	return delay
+}
+
+func (settings *waitSettings) options() []request.WaiterOption {
+	return []request.WaiterOption{
+		request.WithWaiterDelay(settings.delay),
+		// attempts are unlimited, as the timeout bounds the wait instead
+		request.WithWaiterMaxAttempts(0),
+	}
+}
+
+type statusFunc func(ctx context.Context) (string, error)
+
+// wait runs a waiter within the stack's timeout. If the timeout expires, the
+// returned error reports the last status observed.
+func (client *Client) wait(
+	ctx context.Context,
+	stack *config.Stack,
+	waiter func(aws.Context, ...request.WaiterOption) error,
+	describeStatus statusFunc,
+	options ...request.WaiterOption,
+) error {
+	settings := newWaitSettings(ctx, stack)
+
+	waitCtx, cancel := context.WithTimeout(ctx, settings.timeout)
+	defer cancel()
+
+	err := waiter(waitCtx, append(settings.options(), options...)...)
+	if err == nil || !isTimedOut(ctx, waitCtx) {
+		return err
+	}
+
+	// the waiter's context has expired, so use the parent to fetch the status
+	status, statusErr := describeStatus(ctx)
+	if statusErr != nil {
+		status = ""
+	}
+
+	return &TimeoutError{
+		StackName: stack.Name,
+		Status:    status,
+		Timeout:   settings.timeout,
+	}
+}
+
+func (client *Client) describeStackStatus(
+	stack *config.Stack,
+) statusFunc {
+	return func(ctx context.Context) (string, error) {
+		description, err := client.describeStack(ctx, stack)
+		if err != nil {
+			return "", err
+		}
+
+		return aws.StringValue(description.StackStatus), nil
+	}
+}
+
+func (client *Client) describeChangeSetStatus(
+	stack *config.Stack,
+	name string,
+) statusFunc {
+	return func(ctx context.Context) (string, error) {
+		output, err := client.describeChangeSet(ctx, stack, name)
+		if err != nil {
+			return "", err
+		}
+
+		return aws.StringValue(output.Status), nil
+	}
+}
+
+// isTimedOut reports whether the wait context expired while its parent is
+// still live, which distinguishes a timeout from cancellation.
+func isTimedOut(ctx, waitCtx context.Context) bool {
+	return waitCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/72636c/stratus/internal/cli"
+	"github.com/72636c/stratus/internal/command"
 	"github.com/72636c/stratus/internal/context"
+	"github.com/72636c/stratus/internal/log"
+)
+
+const (
+	exitCodeError       = 1
+	exitCodeDrift       = 3
+	exitCodeInterrupted = 130
 )
 
 func main() {
@@ -16,15 +27,44 @@ func main() {
 		return
 	}
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+
+		// restore the default behaviour, so a second signal exits immediately
+		stop()
+	}()
 
 	err = app.Do(ctx)
+	if err != nil && ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, log.Redact(err.Error()))
+		os.Exit(exitCodeInterrupted)
+	}
+
 	check(err)
 }
 
 func check(err error) {
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, log.Redact(err.Error()))
+		os.Exit(toExitCode(err))
 	}
 }
+
+func toExitCode(err error) int {
+	var (
+		driftErr       *command.DriftError
+		interruptedErr *command.InterruptedError
+	)
+
+	if errors.As(err, &driftErr) {
+		return exitCodeDrift
+	}
+
+	if errors.As(err, &interruptedErr) {
+		return exitCodeInterrupted
+	}
+
+	return exitCodeError
+}